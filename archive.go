@@ -0,0 +1,170 @@
+package podrick
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sync"
+
+	"github.com/docker/docker/pkg/fileutils"
+)
+
+// Directory describes a directory tree uploaded to a container.
+type Directory struct {
+	// Content is the directory tree. Use HostDirectory
+	// to upload a directory on the host.
+	Content fs.FS
+	// Path is the absolute path of the directory in the container.
+	Path string
+
+	// Optional
+	// UID and GID set the owner of the directory and
+	// everything in it, which is root by default.
+	UID int
+	GID int
+}
+
+// HostDirectory returns a file system for the directory on the host,
+// for use as the Content of a Directory. Unlike os.DirFS, symlinks
+// in the directory are supported.
+func HostDirectory(dir string) fs.FS {
+	return dirFS{
+		FS:  os.DirFS(dir),
+		dir: dir,
+	}
+}
+
+// dirFS is a file system for a directory on the host,
+// which supports reading symlinks.
+type dirFS struct {
+	fs.FS
+	dir string
+}
+
+func (d dirFS) ReadLink(name string) (string, error) {
+	return os.Readlink(filepath.Join(d.dir, filepath.FromSlash(name)))
+}
+
+// archive returns an archive of the directory tree.
+// The archive is written as it is read.
+func (d Directory) archive() Archive {
+	return Archive{
+		Content: &lazyReader{
+			create: func(w io.Writer) error {
+				return writeDirectory(w, d, nil)
+			},
+		},
+		Path: d.Path,
+	}
+}
+
+// readLinkFS is implemented by file systems which
+// support reading the targets of symlinks.
+type readLinkFS interface {
+	ReadLink(name string) (string, error)
+}
+
+// writeDirectory writes the directory tree to w as a tar archive.
+// Files matching the excludes, if set, are left out.
+func writeDirectory(w io.Writer, d Directory, excludes *fileutils.PatternMatcher) error {
+	archive := tar.NewWriter(w)
+	err := fs.WalkDir(d.Content, ".", func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if excludes != nil && name != "." {
+			excluded, err := excludes.Matches(name)
+			if err != nil {
+				return fmt.Errorf("failed to match %q: %w", name, err)
+			}
+			if excluded {
+				// Files in an excluded directory can only
+				// be included again by exception patterns.
+				if entry.IsDir() && !excludes.Exclusions() {
+					return fs.SkipDir
+				}
+				return nil
+			}
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return fmt.Errorf("failed to stat file: %w", err)
+		}
+		var link string
+		switch {
+		case info.Mode()&fs.ModeSymlink != 0:
+			rl, ok := d.Content.(readLinkFS)
+			if !ok {
+				return fmt.Errorf("unsupported file type for %q: file system does not support symlinks", name)
+			}
+			link, err = rl.ReadLink(name)
+			if err != nil {
+				return fmt.Errorf("failed to read symlink: %w", err)
+			}
+		case !info.Mode().IsRegular() && !info.IsDir():
+			return fmt.Errorf("unsupported file type for %q: %s", name, info.Mode().Type())
+		}
+		hdr, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return fmt.Errorf("failed to create file header: %w", err)
+		}
+		hdr.Name = path.Clean(name)
+		if info.IsDir() {
+			hdr.Name += "/"
+		}
+		hdr.Uid, hdr.Gid = d.UID, d.GID
+		hdr.Uname, hdr.Gname = "", ""
+		err = archive.WriteHeader(hdr)
+		if err != nil {
+			return fmt.Errorf("failed to write file header: %w", err)
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		f, err := d.Content.Open(name)
+		if err != nil {
+			return fmt.Errorf("failed to open file: %w", err)
+		}
+		defer f.Close()
+		_, err = io.Copy(archive, f)
+		if err != nil {
+			return fmt.Errorf("failed to write file contents: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	err = archive.Close()
+	if err != nil {
+		return fmt.Errorf("failed to write tar footer: %w", err)
+	}
+
+	return nil
+}
+
+// lazyReader streams its content as it is read. The content
+// is only created once it is first read, so a reader which
+// is never read holds no resources.
+type lazyReader struct {
+	create func(io.Writer) error
+
+	once sync.Once
+	r    *io.PipeReader
+}
+
+func (l *lazyReader) Read(p []byte) (int, error) {
+	l.once.Do(func() {
+		r, w := io.Pipe()
+		l.r = r
+		go func() {
+			_ = w.CloseWithError(l.create(w))
+		}()
+	})
+	return l.r.Read(p)
+}
@@ -0,0 +1,103 @@
+package podrick
+
+import (
+	"archive/tar"
+	"errors"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+func TestDirectoryArchive(t *testing.T) {
+	d := Directory{
+		Content: fstest.MapFS{
+			"init.sql":       {Data: []byte("SELECT 1;"), Mode: 0644},
+			"conf/app.conf":  {Data: []byte("key=value"), Mode: 0600},
+			"conf/empty.d":   {Mode: 0755 | fs.ModeDir},
+			"conf/other.txt": {Data: nil, Mode: 0644},
+		},
+		Path: "/docker-entrypoint-initdb.d",
+		UID:  999,
+		GID:  998,
+	}
+
+	a := d.archive()
+	if a.Path != d.Path {
+		t.Errorf("Unexpected archive path: got %q, wanted %q", a.Path, d.Path)
+	}
+
+	tr := tar.NewReader(a.Content)
+	got := map[string]string{}
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hdr.Uid != d.UID || hdr.Gid != d.GID {
+			t.Errorf("Unexpected owner of %q: got %d:%d, wanted %d:%d", hdr.Name, hdr.Uid, hdr.Gid, d.UID, d.GID)
+		}
+		content, err := ioutil.ReadAll(tr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got[hdr.Name] = string(content)
+	}
+
+	want := map[string]string{
+		"./":             "",
+		"conf/":          "",
+		"conf/app.conf":  "key=value",
+		"conf/empty.d/":  "",
+		"conf/other.txt": "",
+		"init.sql":       "SELECT 1;",
+	}
+	if len(got) != len(want) {
+		t.Errorf("Unexpected entries: got %q, wanted %q", got, want)
+	}
+	for name, content := range want {
+		if got[name] != content {
+			t.Errorf("Unexpected content of %q: got %q, wanted %q", name, got[name], content)
+		}
+	}
+}
+
+func TestHostDirectoryArchive(t *testing.T) {
+	dir := t.TempDir()
+	err := ioutil.WriteFile(filepath.Join(dir, "app.conf"), []byte("key=value"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.Symlink("app.conf", filepath.Join(dir, "link.conf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a := Directory{Content: HostDirectory(dir), Path: "/etc/app"}.archive()
+	tr := tar.NewReader(a.Content)
+	var found bool
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hdr.Name == "link.conf" {
+			found = true
+			if hdr.Typeflag != tar.TypeSymlink || hdr.Linkname != "app.conf" {
+				t.Errorf("Unexpected symlink: got type %q to %q", hdr.Typeflag, hdr.Linkname)
+			}
+		}
+	}
+	if !found {
+		t.Error("Expected symlink in archive")
+	}
+}
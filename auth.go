@@ -0,0 +1,194 @@
+package podrick
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// RegistryAuth holds the credentials for a container registry.
+type RegistryAuth struct {
+	Username string
+	// Password is the password or access token of the user.
+	Password string
+	// IdentityToken is used instead of the username
+	// and password to authenticate, if set.
+	IdentityToken string
+}
+
+// dockerHubServer is the server address Docker
+// uses for Docker Hub credentials.
+const dockerHubServer = "https://index.docker.io/v1/"
+
+// LookupRegistryAuth looks up the credentials for the registry of the image.
+// The auth file at REGISTRY_AUTH_FILE or $XDG_RUNTIME_DIR/containers/auth.json
+// is searched first, followed by the Docker config at $DOCKER_CONFIG/config.json
+// or ~/.docker/config.json. Credential helpers configured in the files are used.
+// Nil is returned if no credentials were found.
+func LookupRegistryAuth(ctx context.Context, image string) (*RegistryAuth, error) {
+	registry := RegistryHost(image)
+	for _, path := range authFiles() {
+		auth, err := lookupAuthFile(ctx, path, registry)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read credentials from %s: %w", path, err)
+		}
+		if auth != nil {
+			return auth, nil
+		}
+	}
+	return nil, nil
+}
+
+// RegistryHost returns the host of the registry of the image.
+// Images without a registry are on Docker Hub, which is "docker.io".
+func RegistryHost(image string) string {
+	i := strings.IndexRune(image, '/')
+	if i < 0 {
+		return "docker.io"
+	}
+	host := image[:i]
+	if !strings.ContainsAny(host, ".:") && host != "localhost" {
+		return "docker.io"
+	}
+	return normalizeRegistry(host)
+}
+
+// normalizeRegistry strips the scheme and path from the registry,
+// and maps Docker Hub aliases to docker.io.
+func normalizeRegistry(registry string) string {
+	registry = strings.TrimPrefix(registry, "http://")
+	registry = strings.TrimPrefix(registry, "https://")
+	if i := strings.IndexRune(registry, '/'); i >= 0 {
+		registry = registry[:i]
+	}
+	switch registry {
+	case "index.docker.io", "registry-1.docker.io":
+		return "docker.io"
+	}
+	return registry
+}
+
+func authFiles() []string {
+	var files []string
+	if f := os.Getenv("REGISTRY_AUTH_FILE"); f != "" {
+		files = append(files, f)
+	} else if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		files = append(files, filepath.Join(dir, "containers", "auth.json"))
+	}
+	if dir := os.Getenv("DOCKER_CONFIG"); dir != "" {
+		files = append(files, filepath.Join(dir, "config.json"))
+	} else if home, err := os.UserHomeDir(); err == nil {
+		files = append(files, filepath.Join(home, ".docker", "config.json"))
+	}
+	return files
+}
+
+// authFile is the format shared by Docker config
+// files and containers-auth.json files.
+type authFile struct {
+	Auths map[string]struct {
+		Auth          string `json:"auth"`
+		IdentityToken string `json:"identitytoken"`
+	} `json:"auths"`
+	CredHelpers map[string]string `json:"credHelpers"`
+	CredsStore  string            `json:"credsStore"`
+}
+
+func lookupAuthFile(ctx context.Context, path, registry string) (*RegistryAuth, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var f authFile
+	err = json.Unmarshal(data, &f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode auth file: %w", err)
+	}
+
+	server := registry
+	if registry == "docker.io" {
+		server = dockerHubServer
+	}
+	for key, helper := range f.CredHelpers {
+		if normalizeRegistry(key) == registry {
+			return credentialHelper(ctx, helper, key)
+		}
+	}
+	for key, entry := range f.Auths {
+		if normalizeRegistry(key) != registry {
+			continue
+		}
+		if entry.IdentityToken != "" {
+			return &RegistryAuth{IdentityToken: entry.IdentityToken}, nil
+		}
+		if entry.Auth != "" {
+			return decodeAuth(entry.Auth)
+		}
+		// Credentials of empty entries are in the store
+		server = key
+	}
+	if f.CredsStore != "" {
+		return credentialHelper(ctx, f.CredsStore, server)
+	}
+	return nil, nil
+}
+
+func decodeAuth(auth string) (*RegistryAuth, error) {
+	data, err := base64.StdEncoding.DecodeString(auth)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode auth: %w", err)
+	}
+	parts := strings.SplitN(string(data), ":", 2)
+	if len(parts) != 2 {
+		return nil, errors.New("invalid auth, expected username:password")
+	}
+	return &RegistryAuth{
+		Username: parts[0],
+		Password: parts[1],
+	}, nil
+}
+
+// credentialHelper gets the credentials for the server from the
+// credential helper, as described by
+// https://github.com/docker/docker-credential-helpers.
+func credentialHelper(ctx context.Context, helper, server string) (*RegistryAuth, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "docker-credential-"+helper, "get")
+	cmd.Stdin = strings.NewReader(server)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	if err != nil {
+		if strings.Contains(stdout.String(), "credentials not found") {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("credential helper %q failed: %w: %s", helper, err, strings.TrimSpace(stderr.String()+stdout.String()))
+	}
+
+	var creds struct {
+		Username string
+		Secret   string
+	}
+	err = json.Unmarshal(stdout.Bytes(), &creds)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode credential helper output: %w", err)
+	}
+	if creds.Username == "<token>" {
+		return &RegistryAuth{IdentityToken: creds.Secret}, nil
+	}
+	return &RegistryAuth{
+		Username: creds.Username,
+		Password: creds.Secret,
+	}, nil
+}
@@ -0,0 +1,104 @@
+package podrick
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func setEnv(t *testing.T, key, value string) {
+	t.Helper()
+	old, ok := os.LookupEnv(key)
+	err := os.Setenv(key, value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if ok {
+			os.Setenv(key, old)
+		} else {
+			os.Unsetenv(key)
+		}
+	})
+}
+
+func writeFile(t *testing.T, path, content string, mode os.FileMode) {
+	t.Helper()
+	err := ioutil.WriteFile(path, []byte(content), mode)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRegistryHost(t *testing.T) {
+	tests := map[string]string{
+		"alpine:3.10":                          "docker.io",
+		"docker.io/library/alpine:3.10":        "docker.io",
+		"index.docker.io/library/alpine":       "docker.io",
+		"kennethreitz/httpbin:latest":          "docker.io",
+		"quay.io/podman/stable:latest":         "quay.io",
+		"localhost/podrick-test-build:latest":  "localhost",
+		"registry.example.com:5000/app:v1.2.3": "registry.example.com:5000",
+	}
+	for image, want := range tests {
+		if got := RegistryHost(image); got != want {
+			t.Errorf("Unexpected registry for %q: got %q, wanted %q", image, got, want)
+		}
+	}
+}
+
+func TestLookupRegistryAuth(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "auth.json"), `{
+		"auths": {
+			"registry.example.com:5000": {"auth": "dXNlcjpzM2NyM3Q="}
+		}
+	}`, 0600)
+	writeFile(t, filepath.Join(dir, "config.json"), `{
+		"auths": {
+			"https://index.docker.io/v1/": {"auth": "aHViOmh1YnBhc3M="},
+			"registry.example.com:5000": {"auth": "b3RoZXI6b3RoZXI="},
+			"store.example.com": {}
+		},
+		"credHelpers": {
+			"helper.example.com": "podrick-test"
+		},
+		"credsStore": "podrick-test"
+	}`, 0600)
+	writeFile(t, filepath.Join(dir, "docker-credential-podrick-test"), `#!/bin/sh
+read server
+case "$server" in
+helper.example.com) echo '{"ServerURL":"helper.example.com","Username":"helper","Secret":"helperpass"}' ;;
+store.example.com) echo '{"ServerURL":"store.example.com","Username":"<token>","Secret":"storetoken"}' ;;
+*) echo "credentials not found in native keychain"; exit 1 ;;
+esac
+`, 0755)
+
+	setEnv(t, "REGISTRY_AUTH_FILE", filepath.Join(dir, "auth.json"))
+	setEnv(t, "DOCKER_CONFIG", dir)
+	setEnv(t, "PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	tests := []struct {
+		image string
+		want  *RegistryAuth
+	}{
+		// The auth file takes precedence over the docker config
+		{image: "registry.example.com:5000/app:v1", want: &RegistryAuth{Username: "user", Password: "s3cr3t"}},
+		{image: "alpine:3.10", want: &RegistryAuth{Username: "hub", Password: "hubpass"}},
+		{image: "helper.example.com/app:v1", want: &RegistryAuth{Username: "helper", Password: "helperpass"}},
+		{image: "store.example.com/app:v1", want: &RegistryAuth{IdentityToken: "storetoken"}},
+		{image: "quay.io/podman/stable:latest", want: nil},
+	}
+	for _, tt := range tests {
+		got, err := LookupRegistryAuth(context.Background(), tt.image)
+		if err != nil {
+			t.Errorf("Failed to look up credentials for %q: %v", tt.image, err)
+			continue
+		}
+		if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+			t.Errorf("Unexpected credentials for %q: got %+v, wanted %+v", tt.image, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package podrick
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/docker/docker/builder/dockerignore"
+	"github.com/docker/docker/pkg/fileutils"
+	"logur.dev/logur"
+)
+
+// BuildConfig describes an image to build.
+type BuildConfig struct {
+	// Repo and Tag name the built image.
+	// Tag defaults to "latest".
+	Repo string
+	Tag  string
+	// ContextDir is the directory on the host used
+	// as the build context.
+	ContextDir string
+	// Context is a tar archive of the build context.
+	// It is used instead of ContextDir, if set.
+	Context io.Reader
+
+	// Optional
+	// Dockerfile is the path of the Dockerfile in the
+	// build context. Defaults to "Dockerfile".
+	Dockerfile string
+	// BuildArgs are the build-time variables.
+	BuildArgs map[string]string
+	// Target is the build stage to build.
+	// Defaults to the last stage.
+	Target string
+	// Labels are added to the built image.
+	Labels map[string]string
+	// Output receives the output of the build.
+	// If nil, the output is discarded.
+	Output io.Writer
+}
+
+// Image returns the repo and tag of the built image.
+func (b *BuildConfig) Image() (repo, tag string) {
+	tag = b.Tag
+	if tag == "" {
+		tag = "latest"
+	}
+	return b.Repo, tag
+}
+
+// ContextArchive returns a tar archive of the build context. Like docker
+// build, files in ContextDir matching the patterns in its .dockerignore
+// file are left out. The archive is written as it is read, and must
+// be closed to release its resources.
+func (b *BuildConfig) ContextArchive() (io.ReadCloser, error) {
+	if b.Context != nil {
+		return ioutil.NopCloser(b.Context), nil
+	}
+	if b.ContextDir == "" {
+		return nil, errors.New("build context must be provided")
+	}
+	excludes, err := b.excludes()
+	if err != nil {
+		return nil, err
+	}
+	d := Directory{
+		Content: HostDirectory(b.ContextDir),
+	}
+
+	r, w := io.Pipe()
+	go func() {
+		_ = w.CloseWithError(writeDirectory(w, d, excludes))
+	}()
+	return r, nil
+}
+
+// excludes returns the patterns in the .dockerignore file of the context
+// directory, or nil if there is none. Like docker build, the Dockerfile and
+// .dockerignore file are never excluded, since the builder reads them.
+func (b *BuildConfig) excludes() (*fileutils.PatternMatcher, error) {
+	f, err := os.Open(filepath.Join(b.ContextDir, ".dockerignore"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open .dockerignore: %w", err)
+	}
+	defer f.Close()
+	patterns, err := dockerignore.ReadAll(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read .dockerignore: %w", err)
+	}
+
+	dockerfile := b.Dockerfile
+	if dockerfile == "" {
+		dockerfile = "Dockerfile"
+	}
+	for _, keep := range []string{filepath.Clean(dockerfile), ".dockerignore"} {
+		excluded, err := fileutils.Matches(keep, patterns)
+		if err != nil {
+			return nil, fmt.Errorf("invalid .dockerignore pattern: %w", err)
+		}
+		if excluded {
+			patterns = append(patterns, "!"+keep)
+		}
+	}
+
+	excludes, err := fileutils.NewPatternMatcher(patterns)
+	if err != nil {
+		return nil, fmt.Errorf("invalid .dockerignore pattern: %w", err)
+	}
+	return excludes, nil
+}
+
+// StartContainerFromBuild builds an image and starts a container from it,
+// using the configured runtime. The build output is written to the Output
+// of the build, or to the configured Logger at Info level, if it is nil.
+// The built image is not removed when the container is closed.
+// See StartContainer for a description of the port and options.
+func StartContainerFromBuild(ctx context.Context, build BuildConfig, port string, opts ...Option) (_ Container, err error) {
+	if build.Repo == "" {
+		return nil, errors.New("build repo must be provided")
+	}
+	repo, tag := build.Image()
+	conf := newConfig(repo, tag, port, opts...)
+	if build.Output == nil {
+		build.Output = logur.NewWriter(conf.logger)
+	}
+
+	err = conf.runtime.Connect(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to runtime: %w", err)
+	}
+	_, err = conf.runtime.BuildImage(ctx, build)
+	if err != nil {
+		cErr := conf.runtime.Close(context.Background())
+		if cErr != nil {
+			conf.logger.Error("failed to close runtime", map[string]interface{}{
+				"error": cErr.Error(),
+			})
+		}
+		return nil, fmt.Errorf("failed to build image: %w", err)
+	}
+
+	// The container is started on the connection used for the build
+	return start(ctx, conf)
+}
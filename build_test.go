@@ -0,0 +1,86 @@
+package podrick
+
+import (
+	"archive/tar"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBuildConfig(t *testing.T) {
+	dir := t.TempDir()
+	err := ioutil.WriteFile(filepath.Join(dir, "Dockerfile"), []byte("FROM scratch\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.Mkdir(filepath.Join(dir, "src"), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ioutil.WriteFile(filepath.Join(dir, "src", "main.go"), []byte("package main\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.Mkdir(filepath.Join(dir, ".git"), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{
+		".dockerignore": ".git\nDockerfile\n*.log\n!keep.log\n",
+		".git/HEAD":     "ref: refs/heads/master\n",
+		"debug.log":     "debug\n",
+		"keep.log":      "keep\n",
+	} {
+		err = ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = os.Symlink("src/main.go", filepath.Join(dir, "main.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b := BuildConfig{Repo: "example", ContextDir: dir}
+	repo, tag := b.Image()
+	if repo != "example" || tag != "latest" {
+		t.Errorf("Unexpected image: got %s:%s, wanted example:latest", repo, tag)
+	}
+
+	archive, err := b.ContextArchive()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer archive.Close()
+	var names []string
+	tr := tar.NewReader(archive)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, hdr.Name)
+		if hdr.Name == "main.go" && (hdr.Typeflag != tar.TypeSymlink || hdr.Linkname != "src/main.go") {
+			t.Errorf("Unexpected symlink: got type %q to %q", hdr.Typeflag, hdr.Linkname)
+		}
+	}
+	want := []string{"./", ".dockerignore", "Dockerfile", "keep.log", "main.go", "src/", "src/main.go"}
+	if len(names) != len(want) {
+		t.Fatalf("Unexpected entries: got %q, wanted %q", names, want)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Errorf("Unexpected entry %d: got %q, wanted %q", i, names[i], want[i])
+		}
+	}
+
+	_, err = (&BuildConfig{Repo: "example"}).ContextArchive()
+	if err == nil {
+		t.Error("Expected error without build context")
+	}
+}
@@ -0,0 +1,226 @@
+// Command podrick lists and cleans up the containers created by podrick.
+//
+// Usage:
+//
+//	podrick ps [-session id]
+//	podrick logs [-f] <container>
+//	podrick clean -session id | -all
+//	podrick prune [-older-than duration] -session id | -all
+//
+// Removing containers requires a session or -all, so the
+// containers of sessions still running on a shared host
+// are not removed by accident.
+//
+// The runtime is chosen automatically, as by podrick.AutoRuntime,
+// and is configured using the environment variables of the runtime.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/uw-labs/podrick"
+	_ "github.com/uw-labs/podrick/runtimes/docker" // Register auto-runtime
+	_ "github.com/uw-labs/podrick/runtimes/podman" // Register auto-runtime
+)
+
+const usage = `Usage: podrick <command> [flags]
+
+Commands:
+  ps     List the containers created by podrick
+  logs   Print the logs of a container
+  clean  Remove the containers created by podrick
+  prune  Remove the containers created by podrick older than a threshold
+
+Run "podrick <command> -h" for the flags of a command.
+`
+
+// errUsage is returned when the command line is invalid.
+var errUsage = errors.New("invalid usage")
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	err := run(ctx, podrick.AutoRuntime(), os.Args[1:], os.Stdout, os.Stderr)
+	stop()
+	if errors.Is(err, errUsage) {
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "podrick:", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, rt podrick.Runtime, args []string, stdout, stderr io.Writer) (err error) {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return errUsage
+	}
+
+	var (
+		cmd     func(context.Context, podrick.ManagedRuntime, io.Writer) error
+		session *string
+		all     *bool
+	)
+	fs := flag.NewFlagSet("podrick "+args[0], flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	switch args[0] {
+	case "ps":
+		session := fs.String("session", "", "only list the containers of the session")
+		cmd = func(ctx context.Context, rt podrick.ManagedRuntime, w io.Writer) error {
+			return ps(ctx, rt, w, *session, time.Now())
+		}
+	case "logs":
+		follow := fs.Bool("f", false, "follow the log output")
+		cmd = func(ctx context.Context, rt podrick.ManagedRuntime, w io.Writer) error {
+			return rt.ContainerLogs(ctx, fs.Arg(0), *follow, w)
+		}
+	case "clean":
+		session, all = removeFlags(fs)
+		cmd = func(ctx context.Context, rt podrick.ManagedRuntime, w io.Writer) error {
+			return remove(ctx, rt, w, func(c podrick.ContainerInfo) bool {
+				return *all || c.Session() == *session
+			})
+		}
+	case "prune":
+		olderThan := fs.Duration("older-than", time.Hour, "remove the containers older than the duration")
+		session, all = removeFlags(fs)
+		cmd = func(ctx context.Context, rt podrick.ManagedRuntime, w io.Writer) error {
+			cutoff := time.Now().Add(-*olderThan)
+			return remove(ctx, rt, w, func(c podrick.ContainerInfo) bool {
+				return (*all || c.Session() == *session) && c.Created.Before(cutoff)
+			})
+		}
+	case "-h", "-help", "--help", "help":
+		fmt.Fprint(stdout, usage)
+		return nil
+	default:
+		fmt.Fprintf(stderr, "Unknown command %q\n\n%s", args[0], usage)
+		return errUsage
+	}
+	err = fs.Parse(args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return nil
+	}
+	if err != nil {
+		return errUsage
+	}
+	if all != nil && (*session == "") == !*all {
+		fmt.Fprintf(stderr, "Usage: podrick %s requires either -session or -all\n", args[0])
+		return errUsage
+	}
+	if args[0] == "logs" && fs.NArg() != 1 {
+		fmt.Fprintln(stderr, "Usage: podrick logs [-f] <container>")
+		return errUsage
+	}
+
+	err = rt.Connect(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to connect to runtime: %w", err)
+	}
+	defer func() {
+		cErr := rt.Close(context.Background())
+		if err == nil && cErr != nil {
+			err = fmt.Errorf("failed to close runtime: %w", cErr)
+		}
+	}()
+
+	mrt, ok := rt.(podrick.ManagedRuntime)
+	if !ok {
+		return fmt.Errorf("runtime %T does not support managing containers", rt)
+	}
+	return cmd(ctx, mrt, stdout)
+}
+
+// removeFlags defines the flags selecting the
+// sessions whose containers are removed.
+func removeFlags(fs *flag.FlagSet) (session *string, all *bool) {
+	session = fs.String("session", "", "only remove the containers of the session")
+	all = fs.Bool("all", false, "remove the containers of every session, including running ones")
+	return session, all
+}
+
+// listContainers lists the containers, oldest first.
+func listContainers(ctx context.Context, rt podrick.ManagedRuntime) ([]podrick.ContainerInfo, error) {
+	ctrs, err := rt.ListContainers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(ctrs, func(i, j int) bool {
+		return ctrs[i].Created.Before(ctrs[j].Created)
+	})
+	return ctrs, nil
+}
+
+func ps(ctx context.Context, rt podrick.ManagedRuntime, w io.Writer, session string, now time.Time) error {
+	ctrs, err := listContainers(ctx, rt)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(tw, "CONTAINER ID\tIMAGE\tSESSION\tTEST\tSTATE\tAGE")
+	for _, c := range ctrs {
+		if session != "" && c.Session() != session {
+			continue
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			shortID(c.ID), c.Image, c.Session(), c.Labels[podrick.TestLabel], c.State, age(now, c.Created),
+		)
+	}
+	return tw.Flush()
+}
+
+// remove removes the containers matching the filter,
+// and writes the ID of each removed container.
+func remove(ctx context.Context, rt podrick.ManagedRuntime, w io.Writer, filter func(podrick.ContainerInfo) bool) error {
+	ctrs, err := listContainers(ctx, rt)
+	if err != nil {
+		return err
+	}
+	for _, c := range ctrs {
+		if !filter(c) {
+			continue
+		}
+		err = rt.RemoveContainer(ctx, c.ID)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(w, shortID(c.ID))
+	}
+	return nil
+}
+
+func shortID(id string) string {
+	if len(id) > 12 {
+		return id[:12]
+	}
+	return id
+}
+
+// age formats the time since the container
+// was created, for example "5m" or "3d".
+func age(now, created time.Time) string {
+	if created.IsZero() {
+		return "unknown"
+	}
+	d := now.Sub(created)
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	}
+}
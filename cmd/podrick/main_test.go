@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/uw-labs/podrick"
+)
+
+type fakeRuntime struct {
+	podrick.Runtime
+
+	ctrs    []podrick.ContainerInfo
+	removed []string
+	logs    map[string]string
+}
+
+func (f *fakeRuntime) Connect(context.Context) error { return nil }
+func (f *fakeRuntime) Close(context.Context) error   { return nil }
+
+func (f *fakeRuntime) ListContainers(context.Context) ([]podrick.ContainerInfo, error) {
+	return append([]podrick.ContainerInfo(nil), f.ctrs...), nil
+}
+
+func (f *fakeRuntime) RemoveContainer(_ context.Context, id string) error {
+	f.removed = append(f.removed, id)
+	return nil
+}
+
+func (f *fakeRuntime) ContainerLogs(_ context.Context, id string, _ bool, w io.Writer) error {
+	logs, ok := f.logs[id]
+	if !ok {
+		return errors.New("no such container")
+	}
+	_, err := io.WriteString(w, logs)
+	return err
+}
+
+func newFakeRuntime() *fakeRuntime {
+	now := time.Now()
+	return &fakeRuntime{
+		ctrs: []podrick.ContainerInfo{
+			{
+				ID:      "0123456789abcdef",
+				Image:   "docker.io/library/alpine:3.10",
+				Created: now.Add(-3 * time.Hour),
+				State:   "running",
+				Labels: map[string]string{
+					podrick.SessionLabel: "session1",
+					podrick.TestLabel:    "TestOld",
+				},
+			},
+			{
+				ID:      "fedcba9876543210",
+				Image:   "docker.io/kennethreitz/httpbin:latest",
+				Created: now.Add(-5 * time.Minute),
+				State:   "exited",
+				Labels: map[string]string{
+					podrick.SessionLabel: "session2",
+				},
+			},
+		},
+		logs: map[string]string{
+			"0123456789abcdef": "hello\n",
+		},
+	}
+}
+
+func TestPs(t *testing.T) {
+	rt := newFakeRuntime()
+	var out bytes.Buffer
+	err := run(context.Background(), rt, []string{"ps"}, &out, ioutil.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Unexpected output:\n%s", out.String())
+	}
+	for i, want := range [][]string{
+		{"CONTAINER ID", "SESSION", "AGE"},
+		{"0123456789ab", "alpine:3.10", "session1", "TestOld", "running", "3h"},
+		{"fedcba987654", "httpbin:latest", "session2", "exited", "5m"},
+	} {
+		for _, w := range want {
+			if !strings.Contains(lines[i], w) {
+				t.Errorf("Expected line %d to contain %q, got %q", i, w, lines[i])
+			}
+		}
+	}
+
+	out.Reset()
+	err = run(context.Background(), rt, []string{"ps", "-session", "session2"}, &out, ioutil.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(out.String(), "session1") || !strings.Contains(out.String(), "session2") {
+		t.Errorf("Unexpected output:\n%s", out.String())
+	}
+}
+
+func TestLogs(t *testing.T) {
+	var out bytes.Buffer
+	err := run(context.Background(), newFakeRuntime(), []string{"logs", "0123456789abcdef"}, &out, ioutil.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "hello\n" {
+		t.Errorf("Unexpected logs: got %q, wanted %q", out.String(), "hello\n")
+	}
+
+	err = run(context.Background(), newFakeRuntime(), []string{"logs"}, &out, ioutil.Discard)
+	if !errors.Is(err, errUsage) {
+		t.Errorf("Unexpected error: got %v, wanted %v", err, errUsage)
+	}
+}
+
+func TestRemove(t *testing.T) {
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{args: []string{"clean", "-all"}, want: []string{"0123456789abcdef", "fedcba9876543210"}},
+		{args: []string{"clean", "-session", "session2"}, want: []string{"fedcba9876543210"}},
+		{args: []string{"prune", "-all"}, want: []string{"0123456789abcdef"}},
+		{args: []string{"prune", "-older-than", "1m", "-all"}, want: []string{"0123456789abcdef", "fedcba9876543210"}},
+		{args: []string{"prune", "-older-than", "1m", "-session", "session1"}, want: []string{"0123456789abcdef"}},
+	}
+	for _, tt := range tests {
+		rt := newFakeRuntime()
+		err := run(context.Background(), rt, tt.args, ioutil.Discard, ioutil.Discard)
+		if err != nil {
+			t.Fatalf("%v: %v", tt.args, err)
+		}
+		if strings.Join(rt.removed, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("%v: unexpected containers removed: got %q, wanted %q", tt.args, rt.removed, tt.want)
+		}
+	}
+}
+
+func TestUsage(t *testing.T) {
+	for _, args := range [][]string{
+		nil,
+		{"unknown"},
+		{"prune", "-older-than", "soon"},
+		{"clean"},
+		{"prune", "-older-than", "1m"},
+		{"clean", "-all", "-session", "session1"},
+	} {
+		err := run(context.Background(), newFakeRuntime(), args, ioutil.Discard, ioutil.Discard)
+		if !errors.Is(err, errUsage) {
+			t.Errorf("%v: unexpected error: got %v, wanted %v", args, err, errUsage)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package podrick
 
 import (
+	"errors"
 	"io"
 	"os"
 )
@@ -10,6 +11,8 @@ import (
 type ContainerConfig struct {
 	Repo string
 	Tag  string
+	// Port and ExtraPorts are port specs,
+	// as parsed by ParsePortSpec.
 	Port string
 
 	// Optional
@@ -19,6 +22,66 @@ type ContainerConfig struct {
 	Ulimits    []Ulimit
 	Files      []File
 	ExtraPorts []string
+	Mounts     []Mount
+	Archives   []Archive
+	Networks   []NetworkAttachment
+	PullPolicy PullPolicy
+	// RegistryAuth is used to pull the image, if set.
+	// Otherwise, runtimes look up the credentials
+	// using LookupRegistryAuth.
+	RegistryAuth *RegistryAuth
+	// DisableReaper disables the reaper, see StartReaper.
+	DisableReaper bool
+	// Labels are set on the container, in addition
+	// to the labels returned by DefaultLabels.
+	Labels map[string]string
+}
+
+// PortSpecs parses the port specs of the container. If Port is set,
+// it is the first spec returned, followed by any ExtraPorts.
+func (c *ContainerConfig) PortSpecs() ([]PortSpec, error) {
+	var specs []PortSpec
+	for _, s := range append([]string{c.Port}, c.ExtraPorts...) {
+		if s == "" {
+			continue
+		}
+		p, err := ParsePortSpec(s)
+		if err != nil {
+			return nil, err
+		}
+		specs = append(specs, p)
+	}
+	return specs, nil
+}
+
+// PullPolicy describes when the image of a container is pulled.
+type PullPolicy string
+
+// Supported pull policies.
+const (
+	// PullIfNotPresent pulls the image if it is not present
+	// locally. This is the default.
+	PullIfNotPresent PullPolicy = "if-not-present"
+	// PullAlways pulls the image before every start,
+	// so mutable tags like latest are refreshed.
+	PullAlways PullPolicy = "always"
+	// PullNever never pulls the image. Starting a container
+	// fails with ErrImageNotPresent if it is not present locally.
+	PullNever PullPolicy = "never"
+)
+
+// ErrImageNotPresent is returned by runtimes when the image of a
+// container is not present locally and the pull policy is PullNever.
+var ErrImageNotPresent = errors.New("image is not present locally")
+
+// NetworkAttachment describes a network a container is attached to.
+type NetworkAttachment struct {
+	Name string
+
+	// Optional
+	// Aliases are additional names the container
+	// can be reached by on the network.
+	Aliases []string
 }
 
 // Ulimit describes a container ulimit.
@@ -29,10 +92,55 @@ type Ulimit struct {
 }
 
 // File describes a file in a container.
-// All fields are mandatory.
 type File struct {
 	Content io.Reader
 	Path    string
 	Size    int
 	Mode    os.FileMode
+
+	// Optional
+	// UID and GID set the owner of the file,
+	// which is root by default.
+	UID int
+	GID int
+}
+
+// Archive describes a tar archive extracted into a container.
+type Archive struct {
+	// Content is the tar archive.
+	Content io.Reader
+	// Path is the absolute path of the directory the archive
+	// is extracted into. It is created if it does not exist.
+	Path string
+}
+
+// MountType describes the type of a Mount.
+type MountType string
+
+// Supported mount types.
+const (
+	// BindMount mounts a path on the host.
+	BindMount MountType = "bind"
+	// VolumeMount mounts a named volume.
+	VolumeMount MountType = "volume"
+	// TmpfsMount mounts a temporary in-memory filesystem.
+	TmpfsMount MountType = "tmpfs"
+)
+
+// Mount describes a mount in a container.
+type Mount struct {
+	Type MountType
+	// Source is the absolute path on the host for bind mounts
+	// and the name of the volume for volume mounts.
+	// It is ignored for tmpfs mounts. Volumes created by
+	// the mount are removed by the reaper when the session
+	// ends, so create the volume beforehand to keep it.
+	Source string
+	// Target is the absolute path in the container.
+	Target string
+
+	// Optional
+	ReadOnly bool
+	// TmpfsSize is the size limit of a tmpfs mount, in bytes.
+	TmpfsSize int64
 }
@@ -0,0 +1,39 @@
+package podrick
+
+import (
+	"archive/tar"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+)
+
+// CopyFileFrom returns the content of the regular file
+// at the absolute path inside the container.
+func CopyFileFrom(ctx context.Context, ctr Container, path string) (_ []byte, err error) {
+	rc, err := ctr.CopyFrom(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		cErr := rc.Close()
+		if err == nil {
+			err = cErr
+		}
+	}()
+
+	tr := tar.NewReader(rc)
+	hdr, err := tr.Next()
+	if errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("no file found at %q", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file header: %w", err)
+	}
+	if hdr.Typeflag != tar.TypeReg {
+		return nil, fmt.Errorf("%q is not a regular file", path)
+	}
+
+	return ioutil.ReadAll(tr)
+}
@@ -0,0 +1,25 @@
+package podrick
+
+import "io"
+
+// ExecOptions configures a command executed inside
+// a running container. All fields are optional.
+type ExecOptions struct {
+	// Stdin is streamed to the standard input of the command.
+	Stdin io.Reader
+	// Env adds environment variables, in the form
+	// KEY=VALUE, to the environment of the command.
+	Env []string
+	// User is the user, and optionally group, to run the command as.
+	User string
+	// WorkDir is the working directory of the command.
+	WorkDir string
+}
+
+// ExecResult describes the outcome of a command executed
+// inside a running container.
+type ExecResult struct {
+	ExitCode int
+	Stdout   []byte
+	Stderr   []byte
+}
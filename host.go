@@ -0,0 +1,63 @@
+package podrick
+
+import (
+	"net"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// HostOverrideEnv is the environment variable used to override
+// the host the addresses of containers are resolved to. When set,
+// it takes precedence over the host derived from the runtime address
+// and over in-container detection.
+const HostOverrideEnv = "PODRICK_HOST_OVERRIDE"
+
+// RuntimeHost returns the host the published ports of containers are
+// reachable on, given the address of the runtime daemon, such as
+// DOCKER_HOST or PODMAN_VARLINK_ADDRESS. tcp:// and http(s):// addresses
+// resolve to their host. An empty host is returned for local daemons,
+// in which case ports are reachable on the loopback address.
+// The host can be overridden with PODRICK_HOST_OVERRIDE.
+func RuntimeHost(daemonAddress string) string {
+	return runtimeHost(daemonAddress, os.Getenv(HostOverrideEnv))
+}
+
+func runtimeHost(daemonAddress, override string) string {
+	if override != "" {
+		return override
+	}
+	// Varlink TCP addresses are of the form tcp:host:port
+	if strings.HasPrefix(daemonAddress, "tcp:") && !strings.HasPrefix(daemonAddress, "tcp://") {
+		daemonAddress = "tcp://" + strings.TrimPrefix(daemonAddress, "tcp:")
+	}
+	u, err := url.Parse(daemonAddress)
+	if err != nil {
+		return ""
+	}
+	switch u.Scheme {
+	case "tcp", "http", "https":
+	default:
+		// Unix sockets and named pipes are local
+		return ""
+	}
+	host := u.Hostname()
+	if host == "localhost" {
+		return ""
+	}
+	if ip := net.ParseIP(host); ip != nil && (ip.IsLoopback() || ip.IsUnspecified()) {
+		return ""
+	}
+	return host
+}
+
+// InContainer reports whether the current process
+// is running inside a Docker or Podman container.
+func InContainer() bool {
+	for _, f := range []string{"/.dockerenv", "/run/.containerenv"} {
+		if _, err := os.Stat(f); err == nil {
+			return true
+		}
+	}
+	return false
+}
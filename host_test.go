@@ -0,0 +1,50 @@
+package podrick
+
+import "testing"
+
+func TestRuntimeHost(t *testing.T) {
+	tests := []struct {
+		address  string
+		override string
+		want     string
+	}{
+		{address: "", want: ""},
+		{address: "unix:///var/run/docker.sock", want: ""},
+		{address: "unix:/run/podman/io.podman", want: ""},
+		{address: "npipe:////./pipe/docker_engine", want: ""},
+		{address: "tcp://127.0.0.1:2375", want: ""},
+		{address: "tcp://localhost:2375", want: ""},
+		{address: "tcp://docker:2376", want: "docker"},
+		{address: "tcp://192.168.99.100:2376", want: "192.168.99.100"},
+		{address: "tcp:10.0.0.5:12345", want: "10.0.0.5"},
+		{address: "tcp://[fd00::5]:2375", want: "fd00::5"},
+		{address: "tcp://docker:2376", override: "10.0.0.1", want: "10.0.0.1"},
+		{address: "unix:///var/run/docker.sock", override: "host.docker.internal", want: "host.docker.internal"},
+	}
+	for _, tt := range tests {
+		got := runtimeHost(tt.address, tt.override)
+		if got != tt.want {
+			t.Errorf("Unexpected host for %q (override %q): got %q, wanted %q", tt.address, tt.override, got, tt.want)
+		}
+	}
+}
+
+func TestPortBindingHostAddress(t *testing.T) {
+	tests := []struct {
+		binding PortBinding
+		host    string
+		want    string
+	}{
+		{binding: NewPortBinding("0.0.0.0", "32768"), host: "", want: "127.0.0.1:32768"},
+		{binding: NewPortBinding("0.0.0.0", "32768"), host: "docker", want: "docker:32768"},
+		{binding: NewPortBinding("::", "32768"), host: "docker", want: "docker:32768"},
+		{binding: NewPortBinding("127.0.0.1", "8080"), host: "fd00::5", want: "[fd00::5]:8080"},
+		{binding: NewPortBinding("10.0.0.5", "8080"), host: "docker", want: "10.0.0.5:8080"},
+	}
+	for _, tt := range tests {
+		got := tt.binding.HostAddress(tt.host)
+		if got != tt.want {
+			t.Errorf("Unexpected address for %+v on %q: got %q, wanted %q", tt.binding, tt.host, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package podrick
+
+import (
+	"runtime/debug"
+	"sync"
+)
+
+// Labels set by podrick on the resources it creates.
+const (
+	// VersionLabel records the version of podrick.
+	VersionLabel = "podrick.version"
+	// TestLabel records the name of the test that
+	// created the container, see WithTestName.
+	TestLabel = "podrick.test"
+)
+
+const modulePath = "github.com/uw-labs/podrick"
+
+var (
+	versionOnce sync.Once
+	version     string
+)
+
+// Version returns the version of podrick, as recorded in
+// the build information of the binary, or "devel" if
+// it is not known.
+func Version() string {
+	versionOnce.Do(func() {
+		version = "devel"
+		info, ok := debug.ReadBuildInfo()
+		if !ok {
+			return
+		}
+		mod := &info.Main
+		for _, dep := range info.Deps {
+			if dep.Path == modulePath {
+				mod = dep
+			}
+		}
+		if mod.Path == modulePath && mod.Version != "" && mod.Version != "(devel)" {
+			version = mod.Version
+		}
+	})
+	return version
+}
+
+// DefaultLabels returns the labels set on every
+// container and network created by podrick.
+func DefaultLabels() map[string]string {
+	return map[string]string{
+		SessionLabel: SessionID(),
+		VersionLabel: Version(),
+	}
+}
+
+// AllLabels returns the labels of the container, including
+// the default labels, which can't be overridden.
+func (c *ContainerConfig) AllLabels() map[string]string {
+	labels := make(map[string]string, len(c.Labels)+2)
+	for k, v := range c.Labels {
+		labels[k] = v
+	}
+	for k, v := range DefaultLabels() {
+		labels[k] = v
+	}
+	return labels
+}
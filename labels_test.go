@@ -0,0 +1,36 @@
+package podrick
+
+import "testing"
+
+func TestLabels(t *testing.T) {
+	conf := newConfig("alpine", "3.10", "",
+		WithLabels(map[string]string{
+			"app":        "example",
+			SessionLabel: "overridden",
+		}),
+		WithLabels(map[string]string{
+			"team": "platform",
+		}),
+		WithTestName("TestLabels"),
+	)
+
+	labels := conf.AllLabels()
+	want := map[string]string{
+		"app":        "example",
+		"team":       "platform",
+		TestLabel:    "TestLabels",
+		SessionLabel: SessionID(),
+		VersionLabel: Version(),
+	}
+	if len(labels) != len(want) {
+		t.Errorf("Unexpected labels: got %v, wanted %v", labels, want)
+	}
+	for k, v := range want {
+		if labels[k] != v {
+			t.Errorf("Unexpected value for label %q: got %q, wanted %q", k, labels[k], v)
+		}
+	}
+	if Version() == "" {
+		t.Error("Expected a version")
+	}
+}
@@ -1,5 +1,7 @@
 package podrick
 
+import "regexp"
+
 // Option configures the configuration of the started container.
 type Option func(*config)
 
@@ -50,14 +52,53 @@ func WithRuntime(in Runtime) Option {
 
 // WithLivenessCheck defines a function to call repeatedly until it does not
 // error, to ascertain the successful startup of the container. The
-// function will be retried for 10 seconds, and if it does not return
+// function will be retried for 30 seconds, and if it does not return
 // a non-nil error before that time, the last error will be returned.
+// Use WithStartupPolicy to configure the retries.
 func WithLivenessCheck(lc LivenessCheck) Option {
 	return func(c *config) {
 		c.liveCheck = lc
 	}
 }
 
+// WithWaitStrategy defines a strategy used to ascertain the successful
+// startup of the container. The strategy is waited for after any
+// liveness check. This can be specified multiple times, in which
+// case all the strategies are waited for, in order. The strategies
+// will be given 30 seconds in total to succeed. Use WithStartupPolicy
+// to configure the time allowed.
+func WithWaitStrategy(ws WaitStrategy) Option {
+	return func(c *config) {
+		c.waitStrategies = append(c.waitStrategies, ws)
+	}
+}
+
+// WithWaitForLog waits for the container to log a line matching
+// the pattern the specified number of times, to ascertain the successful
+// startup of the container. The logs are matched as they are streamed
+// to the configured logger. The pattern will be waited for for 30 seconds,
+// and if it has not been seen enough times before that time, an error
+// including the last lines logged by the container will be returned.
+// This can be specified multiple times.
+func WithWaitForLog(pattern *regexp.Regexp, occurrences int) Option {
+	return func(c *config) {
+		c.logWaits = append(c.logWaits, logWait{
+			pattern:     pattern,
+			occurrences: occurrences,
+		})
+	}
+}
+
+// WithStartupPolicy configures the time allowed for the container to
+// become ready, and the retry policy of the liveness check. By default,
+// the container is given 30 seconds, and the liveness check is
+// retried with an exponential backoff.
+func WithStartupPolicy(p StartupPolicy) Option {
+	return func(c *config) {
+		c.startupPolicy = p
+	}
+}
+
 // WithFileUpload writes the content of the reader to the provided path
 // inside the container, before starting the container. This can
 // be specified multiple times.
@@ -67,8 +108,99 @@ func WithFileUpload(f File) Option {
 	}
 }
 
+// WithMount mounts a host path, named volume or
+// tmpfs in the container. This can be specified
+// multiple times.
+func WithMount(m Mount) Option {
+	return func(c *config) {
+		c.Mounts = append(c.Mounts, m)
+	}
+}
+
+// WithDirectoryUpload writes the directory tree to the provided path
+// inside the container, before starting the container. This can
+// be specified multiple times.
+func WithDirectoryUpload(d Directory) Option {
+	return func(c *config) {
+		c.Archives = append(c.Archives, d.archive())
+	}
+}
+
+// WithTarUpload extracts the tar archive into the provided path
+// inside the container, before starting the container. This can
+// be specified multiple times.
+func WithTarUpload(a Archive) Option {
+	return func(c *config) {
+		c.Archives = append(c.Archives, a)
+	}
+}
+
+// WithNetwork attaches the container to the network, which must
+// have been created with the CreateNetwork method of the runtime.
+// Other containers on the network can reach the container using the aliases.
+// This can be specified multiple times.
+func WithNetwork(name string, aliases ...string) Option {
+	return func(c *config) {
+		c.Networks = append(c.Networks, NetworkAttachment{
+			Name:    name,
+			Aliases: aliases,
+		})
+	}
+}
+
+// WithPullPolicy configures when the image of the container is pulled.
+// By default, the image is pulled if it is not present locally.
+func WithPullPolicy(policy PullPolicy) Option {
+	return func(c *config) {
+		c.PullPolicy = policy
+	}
+}
+
+// WithRegistryAuth configures the credentials used to pull the image.
+// The password may be an access token. By default, credentials are
+// looked up in the auth files of the user, see LookupRegistryAuth.
+func WithRegistryAuth(username, password string) Option {
+	return func(c *config) {
+		c.RegistryAuth = &RegistryAuth{
+			Username: username,
+			Password: password,
+		}
+	}
+}
+
+// WithReaper configures whether a reaper is started, which removes
+// the container if the process exits without closing it. By default,
+// a reaper is started, unless PODRICK_REAPER_DISABLED is set to true.
+func WithReaper(enabled bool) Option {
+	return func(c *config) {
+		c.DisableReaper = !enabled
+	}
+}
+
+// WithLabels adds labels to the container. The labels returned
+// by DefaultLabels are always set, and can't be overridden.
+func WithLabels(labels map[string]string) Option {
+	return func(c *config) {
+		if c.Labels == nil {
+			c.Labels = make(map[string]string, len(labels))
+		}
+		for k, v := range labels {
+			c.Labels[k] = v
+		}
+	}
+}
+
+// WithTestName records the name of the test starting
+// the container in the podrick.test label.
+func WithTestName(name string) Option {
+	return WithLabels(map[string]string{
+		TestLabel: name,
+	})
+}
+
 // WithExposePort adds extra ports that should be exposed from the
-// started container.
+// started container. The port may be a port spec, as parsed by
+// ParsePortSpec, to publish it on a fixed host IP or port.
 func WithExposePort(port string) Option {
 	return func(c *config) {
 		c.ExtraPorts = append(c.ExtraPorts, port)
@@ -82,7 +214,15 @@ type LivenessCheck func(address string) error
 type config struct {
 	ContainerConfig
 
-	logger    Logger
-	runtime   Runtime
-	liveCheck LivenessCheck
+	logger         Logger
+	runtime        Runtime
+	liveCheck      LivenessCheck
+	waitStrategies []WaitStrategy
+	logWaits       []logWait
+	startupPolicy  StartupPolicy
+}
+
+type logWait struct {
+	pattern     *regexp.Regexp
+	occurrences int
 }
@@ -1,8 +1,10 @@
 package podrick
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"time"
 
 	backoff "github.com/cenkalti/backoff/v3"
@@ -11,24 +13,22 @@ import (
 
 // StartContainer starts a container using the configured runtime.
 // By default, a runtime is chosen automatically from those registered.
+// The port may be a port spec, as parsed by ParsePortSpec, to publish
+// it on a fixed host IP or port. The Address of the container is the
+// address of the container port.
 func StartContainer(ctx context.Context, repo, tag, port string, opts ...Option) (_ Container, err error) {
-	conf := config{
-		ContainerConfig: ContainerConfig{
-			Repo: repo,
-			Tag:  tag,
-			Port: port,
-		},
-		logger:  logur.NewNoopLogger(),
-		runtime: &autoRuntime{},
-	}
-	for _, o := range opts {
-		o(&conf)
-	}
-
+	conf := newConfig(repo, tag, port, opts...)
 	err = conf.runtime.Connect(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to runtime: %w", err)
 	}
+	return start(ctx, conf)
+}
+
+// start starts the container using the connected runtime, and waits
+// for it to be ready as configured by the liveness check and wait
+// strategies. The runtime is closed if the container fails to start.
+func start(ctx context.Context, conf *config) (_ Container, err error) {
 	defer func() {
 		if err != nil {
 			cErr := conf.runtime.Close(context.Background())
@@ -40,9 +40,17 @@ func StartContainer(ctx context.Context, repo, tag, port string, opts ...Option)
 		}
 	}()
 
-	ctr, err := conf.runtime.StartContainer(ctx, &conf.ContainerConfig)
+	logs := []io.Writer{logur.NewWriter(conf.logger)}
+	var matchers []*logMatcher
+	for _, lw := range conf.logWaits {
+		m := newLogMatcher(lw.pattern, lw.occurrences)
+		matchers = append(matchers, m)
+		logs = append(logs, m)
+	}
+
+	ctr, err := startContainer(ctx, conf, io.MultiWriter(logs...))
 	if err != nil {
-		return nil, fmt.Errorf("failed to start container: %w", err)
+		return nil, err
 	}
 	defer func() {
 		if err != nil {
@@ -55,31 +63,152 @@ func StartContainer(ctx context.Context, repo, tag, port string, opts ...Option)
 		}
 	}()
 
-	err = ctr.StreamLogs(ctx, logur.NewWriter(conf.logger))
-	if err != nil {
-		return nil, fmt.Errorf("failed to stream container logs: %w", err)
+	sctx, cancel := context.WithTimeout(ctx, conf.startupPolicy.timeout())
+	defer cancel()
+
+	for _, m := range matchers {
+		err = m.wait(sctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to wait for log: %w", err)
+		}
 	}
 
 	if conf.liveCheck != nil {
-		bk := backoff.NewExponentialBackOff()
-		bk.MaxElapsedTime = 30 * time.Second
-		cbk := backoff.WithContext(bk, ctx)
+		start := time.Now()
+		attempts := 0
 		err = backoff.RetryNotify(
 			func() error {
+				attempts++
 				return conf.liveCheck(ctr.Address())
 			},
-			cbk,
+			backoff.WithContext(conf.startupPolicy.backOff(), sctx),
 			func(err error, next time.Duration) {
 				conf.logger.Error("Liveness check failed", map[string]interface{}{
 					"retry_in": next.Truncate(time.Millisecond).String(),
+					"attempt":  attempts,
 					"error":    err.Error(),
 				})
 			},
 		)
 		if err != nil {
-			return nil, fmt.Errorf("liveness check failed: %w", err)
+			return nil, fmt.Errorf(
+				"liveness check failed after %d attempts in %s: %w",
+				attempts, time.Since(start).Truncate(time.Millisecond), err,
+			)
+		}
+	}
+
+	if len(conf.waitStrategies) > 0 {
+		err = All(conf.waitStrategies...).WaitUntilReady(sctx, ctr)
+		if err != nil {
+			return nil, fmt.Errorf("wait strategy failed: %w", err)
 		}
 	}
 
 	return ctr, nil
 }
+
+// RunResult describes the outcome of a container
+// run to completion.
+type RunResult struct {
+	ExitCode int
+	// Output contains the logs written by the container.
+	Output []byte
+}
+
+// RunContainer runs a container to completion using the configured runtime,
+// and returns the exit code and output of the container. Unlike StartContainer,
+// the container does not need to expose any ports. A non-zero exit code
+// is not considered an error. The container is removed before returning.
+// By default, a runtime is chosen automatically from those registered.
+func RunContainer(ctx context.Context, repo, tag string, opts ...Option) (_ RunResult, err error) {
+	conf := newConfig(repo, tag, "", opts...)
+	err = conf.runtime.Connect(ctx)
+	if err != nil {
+		return RunResult{}, fmt.Errorf("failed to connect to runtime: %w", err)
+	}
+	defer func() {
+		// The runtime is closed even if removing the container
+		// fails, so its connection is released.
+		cErr := conf.runtime.Close(context.Background())
+		if cErr != nil {
+			if err == nil {
+				err = fmt.Errorf("failed to close runtime: %w", cErr)
+				return
+			}
+			conf.logger.Error("failed to close runtime", map[string]interface{}{
+				"error": cErr.Error(),
+			})
+		}
+	}()
+
+	var output bytes.Buffer
+	ctr, err := startContainer(ctx, conf, io.MultiWriter(logur.NewWriter(conf.logger), &output))
+	if err != nil {
+		return RunResult{}, err
+	}
+	defer func() {
+		cErr := ctr.Close(context.Background())
+		if cErr != nil {
+			if err == nil {
+				err = fmt.Errorf("failed to clean up container: %w", cErr)
+				return
+			}
+			conf.logger.Error("failed to clean up container", map[string]interface{}{
+				"error": cErr.Error(),
+			})
+		}
+	}()
+
+	exitCode, err := ctr.Wait(ctx)
+	if err != nil {
+		return RunResult{}, fmt.Errorf("failed to wait for container: %w", err)
+	}
+
+	return RunResult{
+		ExitCode: exitCode,
+		Output:   output.Bytes(),
+	}, nil
+}
+
+func newConfig(repo, tag, port string, opts ...Option) *config {
+	conf := &config{
+		ContainerConfig: ContainerConfig{
+			Repo: repo,
+			Tag:  tag,
+			Port: port,
+		},
+		logger:  logur.NewNoopLogger(),
+		runtime: &autoRuntime{},
+	}
+	for _, o := range opts {
+		o(conf)
+	}
+	return conf
+}
+
+// startContainer starts the container using the connected
+// runtime and streams the container logs to the writer.
+func startContainer(ctx context.Context, conf *config, logs io.Writer) (_ Container, err error) {
+	ctr, err := conf.runtime.StartContainer(ctx, &conf.ContainerConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start container: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			cErr := ctr.Close(context.Background())
+			if cErr != nil {
+				conf.logger.Error("failed to close container", map[string]interface{}{
+					"error": cErr.Error(),
+				})
+			}
+		}
+	}()
+
+	err = ctr.StreamLogs(ctx, logs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stream container logs: %w", err)
+	}
+
+	return ctr, nil
+}
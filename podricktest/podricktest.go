@@ -0,0 +1,137 @@
+// Package podricktest integrates podrick with the testing package.
+package podricktest
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/uw-labs/podrick"
+)
+
+// logTailLines is the number of lines of container
+// logs that are logged when a test fails.
+const logTailLines = 50
+
+// Start starts a container with podrick.StartContainer and
+// fails the test if it can't be started. The container is
+// closed when the test and all its subtests complete.
+//
+// The logs of the container are captured, and the last lines
+// are logged if the test fails. Other podrick events are logged
+// with t.Log. The options are applied after those set by Start,
+// so configuring a logger with podrick.WithLogger disables
+// capturing the logs.
+func Start(t testing.TB, repo, tag, port string, opts ...podrick.Option) podrick.Container {
+	t.Helper()
+	logs := &tailLogger{
+		Logger: Logger(t),
+	}
+	opts = append([]podrick.Option{
+		podrick.WithLogger(logs),
+		podrick.WithTestName(t.Name()),
+	}, opts...)
+
+	ctr, err := podrick.StartContainer(context.Background(), repo, tag, port, opts...)
+	if err != nil {
+		logs.dump(t)
+		t.Fatalf("Failed to start container %s:%s: %v", repo, tag, err)
+	}
+	t.Cleanup(func() {
+		err := ctr.Close(context.Background())
+		if err != nil {
+			t.Errorf("Failed to close container %s:%s: %v", repo, tag, err)
+		}
+		if t.Failed() {
+			logs.dump(t)
+		}
+	})
+
+	return ctr
+}
+
+// Logger returns a podrick.Logger which logs to the test.
+func Logger(t testing.TB) podrick.Logger {
+	return testLogger{t: t}
+}
+
+type testLogger struct {
+	t testing.TB
+}
+
+func (l testLogger) log(level, msg string, fields []map[string]interface{}) {
+	var b strings.Builder
+	b.WriteString(level)
+	b.WriteString(" ")
+	b.WriteString(msg)
+	for _, f := range fields {
+		keys := make([]string, 0, len(f))
+		for k := range f {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fmt.Fprintf(&b, " %s=%v", k, f[k])
+		}
+	}
+	l.t.Log(b.String())
+}
+
+func (l testLogger) Trace(msg string, fields ...map[string]interface{}) {
+	l.log("TRACE", msg, fields)
+}
+
+func (l testLogger) Debug(msg string, fields ...map[string]interface{}) {
+	l.log("DEBUG", msg, fields)
+}
+
+func (l testLogger) Info(msg string, fields ...map[string]interface{}) {
+	l.log("INFO", msg, fields)
+}
+
+func (l testLogger) Warn(msg string, fields ...map[string]interface{}) {
+	l.log("WARN", msg, fields)
+}
+
+func (l testLogger) Error(msg string, fields ...map[string]interface{}) {
+	l.log("ERROR", msg, fields)
+}
+
+// tailLogger keeps the last lines logged at Info level,
+// which podrick uses for the logs of the container,
+// and passes other events to the Logger.
+type tailLogger struct {
+	podrick.Logger
+
+	mu      sync.Mutex
+	lines   []string
+	dropped int
+}
+
+func (l *tailLogger) Info(msg string, _ ...map[string]interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.lines = append(l.lines, msg)
+	if len(l.lines) > logTailLines {
+		l.dropped += len(l.lines) - logTailLines
+		l.lines = l.lines[len(l.lines)-logTailLines:]
+	}
+}
+
+// dump logs the captured lines to the test.
+func (l *tailLogger) dump(t testing.TB) {
+	t.Helper()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.lines) == 0 {
+		return
+	}
+	header := "Container logs:"
+	if l.dropped > 0 {
+		header = fmt.Sprintf("Last %d lines of container logs:", len(l.lines))
+	}
+	t.Log(header + "\n" + strings.Join(l.lines, "\n"))
+}
@@ -0,0 +1,57 @@
+package podricktest
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// fakeTB records the messages logged by the test.
+type fakeTB struct {
+	testing.TB
+	logs []string
+}
+
+func (f *fakeTB) Helper() {}
+
+func (f *fakeTB) Log(args ...interface{}) {
+	f.logs = append(f.logs, fmt.Sprint(args...))
+}
+
+func TestLogger(t *testing.T) {
+	tb := &fakeTB{}
+	Logger(tb).Error("liveness check failed", map[string]interface{}{
+		"error":   "connection refused",
+		"attempt": 2,
+	})
+	want := "ERROR liveness check failed attempt=2 error=connection refused"
+	if len(tb.logs) != 1 || tb.logs[0] != want {
+		t.Errorf("Unexpected logs: got %q, wanted %q", tb.logs, want)
+	}
+}
+
+func TestTailLogger(t *testing.T) {
+	tb := &fakeTB{}
+	l := &tailLogger{
+		Logger: Logger(tb),
+	}
+	for i := 0; i < logTailLines+10; i++ {
+		l.Info(fmt.Sprintf("line %d", i))
+	}
+	l.Warn("not captured")
+	if len(tb.logs) != 1 || tb.logs[0] != "WARN not captured" {
+		t.Fatalf("Unexpected logs: %q", tb.logs)
+	}
+
+	l.dump(tb)
+	if len(tb.logs) != 2 {
+		t.Fatalf("Unexpected logs: %q", tb.logs)
+	}
+	lines := strings.Split(tb.logs[1], "\n")
+	if lines[0] != fmt.Sprintf("Last %d lines of container logs:", logTailLines) {
+		t.Errorf("Unexpected header: %q", lines[0])
+	}
+	if len(lines) != logTailLines+1 || lines[1] != "line 10" || lines[logTailLines] != fmt.Sprintf("line %d", logTailLines+9) {
+		t.Errorf("Unexpected tail: %q", lines[1:])
+	}
+}
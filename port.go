@@ -0,0 +1,216 @@
+package podrick
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrPortInUse is returned by runtimes when a container
+// could not be started because a fixed host port was
+// already in use.
+var ErrPortInUse = errors.New("host port is already in use")
+
+// Port is a container port and its protocol.
+type Port struct {
+	// Number is the port number, for example "80".
+	Number string
+	// Protocol is either tcp or udp.
+	Protocol string
+}
+
+// String returns the port in the format "80/tcp".
+func (p Port) String() string {
+	return p.Number + "/" + p.Protocol
+}
+
+// PortBinding is a host address a container port is published on.
+type PortBinding struct {
+	// HostIP is the host IP the port is bound to,
+	// as reported by the runtime. It may be a wildcard
+	// address, such as 0.0.0.0 or ::.
+	HostIP string
+	// HostPort is the host port the port is bound to.
+	HostPort string
+	// Family is either "ipv4" or "ipv6".
+	Family string
+}
+
+// NewPortBinding returns a binding for the host IP and port,
+// with the address family derived from the host IP.
+// An empty host IP is considered an IPv4 wildcard address.
+func NewPortBinding(hostIP, hostPort string) PortBinding {
+	family := "ipv4"
+	if ip := net.ParseIP(hostIP); ip != nil && ip.To4() == nil {
+		family = "ipv6"
+	}
+	return PortBinding{
+		HostIP:   hostIP,
+		HostPort: hostPort,
+		Family:   family,
+	}
+}
+
+// Address returns the address of the binding. Wildcard host IPs
+// are translated to the loopback address of the same family,
+// so the address can be dialled directly.
+func (b PortBinding) Address() string {
+	return b.HostAddress("")
+}
+
+// HostAddress returns the address of the binding on the runtime host,
+// as returned by RuntimeHost. Wildcard and loopback host IPs are
+// replaced by the host, unless it is empty, in which case wildcard
+// host IPs are translated as by Address.
+func (b PortBinding) HostAddress(host string) string {
+	ip := b.HostIP
+	switch {
+	case host != "" && (ip == "" || net.ParseIP(ip).IsUnspecified() || net.ParseIP(ip).IsLoopback()):
+		ip = host
+	case b.Family == "ipv6" && (ip == "" || net.ParseIP(ip).IsUnspecified()):
+		ip = "::1"
+	case ip == "" || net.ParseIP(ip).IsUnspecified():
+		ip = "127.0.0.1"
+	}
+	return net.JoinHostPort(ip, b.HostPort)
+}
+
+// rank orders bindings by how likely they are to be
+// dialable from the host, lowest first.
+func (b PortBinding) rank() int {
+	ip := net.ParseIP(b.HostIP)
+	local := b.HostIP == "" || ip.IsUnspecified() || ip.IsLoopback()
+	switch {
+	case local && b.Family == "ipv4":
+		return 0
+	case local:
+		return 1
+	case b.Family == "ipv4":
+		return 2
+	default:
+		return 3
+	}
+}
+
+// SortPortBindings sorts the bindings deterministically, with
+// loopback and wildcard IPv4 bindings first, followed by loopback
+// and wildcard IPv6 bindings and then any others. Runtimes use
+// the first binding as the address of the port.
+func SortPortBindings(bindings []PortBinding) {
+	sort.Slice(bindings, func(i, j int) bool {
+		bi, bj := bindings[i], bindings[j]
+		if bi.rank() != bj.rank() {
+			return bi.rank() < bj.rank()
+		}
+		if bi.HostIP != bj.HostIP {
+			return bi.HostIP < bj.HostIP
+		}
+		return bi.HostPort < bj.HostPort
+	})
+}
+
+// PortSpec describes a container port published on the host.
+type PortSpec struct {
+	// HostIP is the host IP the port is published on.
+	// If empty, the port is published on all interfaces.
+	HostIP string
+	// HostPort is the host port the container port is
+	// published on. If empty, a random port is chosen.
+	HostPort string
+	// ContainerPort is the port inside the container.
+	ContainerPort string
+	// Protocol is either tcp or udp. If empty, tcp is used.
+	Protocol string
+}
+
+// ParsePortSpec parses a port specification of the form
+// [[hostIP:]hostPort:]containerPort[/protocol], where protocol
+// is either tcp or udp, for example
+// "80", "8080:80", "127.0.0.1:8080:80", "127.0.0.1::80",
+// "[::1]:8080:80" or "53/udp".
+func ParsePortSpec(spec string) (PortSpec, error) {
+	var p PortSpec
+	rest := spec
+	if i := strings.LastIndex(rest, "/"); i >= 0 {
+		p.Protocol = strings.ToLower(rest[i+1:])
+		rest = rest[:i]
+		if p.Protocol != "tcp" && p.Protocol != "udp" {
+			return PortSpec{}, fmt.Errorf("invalid protocol in port spec %q", spec)
+		}
+	}
+
+	if strings.HasPrefix(rest, "[") {
+		end := strings.Index(rest, "]:")
+		if end < 0 {
+			return PortSpec{}, fmt.Errorf("invalid host IP in port spec %q", spec)
+		}
+		p.HostIP = rest[1:end]
+		rest = rest[end+2:]
+		parts := strings.Split(rest, ":")
+		if len(parts) != 2 {
+			return PortSpec{}, fmt.Errorf("invalid port spec %q", spec)
+		}
+		p.HostPort, p.ContainerPort = parts[0], parts[1]
+	} else {
+		parts := strings.Split(rest, ":")
+		switch len(parts) {
+		case 1:
+			p.ContainerPort = parts[0]
+		case 2:
+			p.HostPort, p.ContainerPort = parts[0], parts[1]
+		case 3:
+			p.HostIP, p.HostPort, p.ContainerPort = parts[0], parts[1], parts[2]
+		default:
+			return PortSpec{}, fmt.Errorf("invalid port spec %q", spec)
+		}
+	}
+
+	if p.HostIP != "" && net.ParseIP(p.HostIP) == nil {
+		return PortSpec{}, fmt.Errorf("invalid host IP in port spec %q", spec)
+	}
+	if p.HostPort != "" && !validPort(p.HostPort) {
+		return PortSpec{}, fmt.Errorf("invalid host port in port spec %q", spec)
+	}
+	if !validPort(p.ContainerPort) {
+		return PortSpec{}, fmt.Errorf("invalid container port in port spec %q", spec)
+	}
+
+	return p, nil
+}
+
+func validPort(port string) bool {
+	n, err := strconv.ParseUint(port, 10, 16)
+	return err == nil && n > 0
+}
+
+// Port returns the container port of the spec.
+// The protocol defaults to tcp.
+func (p PortSpec) Port() Port {
+	proto := p.Protocol
+	if proto == "" {
+		proto = "tcp"
+	}
+	return Port{Number: p.ContainerPort, Protocol: proto}
+}
+
+// String returns the port spec in the format parsed by ParsePortSpec.
+func (p PortSpec) String() string {
+	s := p.ContainerPort
+	if p.HostIP != "" || p.HostPort != "" {
+		s = p.HostPort + ":" + s
+	}
+	if p.HostIP != "" {
+		hostIP := p.HostIP
+		if strings.Contains(hostIP, ":") {
+			hostIP = "[" + hostIP + "]"
+		}
+		s = hostIP + ":" + s
+	}
+	if p.Protocol != "" {
+		s += "/" + p.Protocol
+	}
+	return s
+}
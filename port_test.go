@@ -0,0 +1,83 @@
+package podrick
+
+import "testing"
+
+func TestParsePortSpec(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    PortSpec
+		wantErr bool
+	}{
+		{spec: "80", want: PortSpec{ContainerPort: "80"}},
+		{spec: "53/udp", want: PortSpec{ContainerPort: "53", Protocol: "udp"}},
+		{spec: "8080:80", want: PortSpec{HostPort: "8080", ContainerPort: "80"}},
+		{spec: "127.0.0.1:8080:80/tcp", want: PortSpec{HostIP: "127.0.0.1", HostPort: "8080", ContainerPort: "80", Protocol: "tcp"}},
+		{spec: "127.0.0.1::80", want: PortSpec{HostIP: "127.0.0.1", ContainerPort: "80"}},
+		{spec: "[::1]:8080:80", want: PortSpec{HostIP: "::1", HostPort: "8080", ContainerPort: "80"}},
+		{spec: "", wantErr: true},
+		{spec: "http", wantErr: true},
+		{spec: "80/sctp", wantErr: true},
+		{spec: "70000", wantErr: true},
+		{spec: "localhost:8080:80", wantErr: true},
+		{spec: "1:2:3:4", wantErr: true},
+		{spec: "[::1]:80", wantErr: true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.spec, func(t *testing.T) {
+			got, err := ParsePortSpec(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Unexpected error: got %v, wanted error %t", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got != tt.want {
+				t.Errorf("Unexpected port spec: got %+v, wanted %+v", got, tt.want)
+			}
+			if got.String() != tt.spec {
+				t.Errorf("Unexpected string: got %q, wanted %q", got.String(), tt.spec)
+			}
+		})
+	}
+}
+
+func TestPortSpecPort(t *testing.T) {
+	tests := map[string]Port{
+		"80":                {Number: "80", Protocol: "tcp"},
+		"8080:80/tcp":       {Number: "80", Protocol: "tcp"},
+		"127.0.0.1::53/udp": {Number: "53", Protocol: "udp"},
+	}
+	for spec, want := range tests {
+		p, err := ParsePortSpec(spec)
+		if err != nil {
+			t.Fatalf("Failed to parse port spec %q: %v", spec, err)
+		}
+		if got := p.Port(); got != want {
+			t.Errorf("Unexpected port for %q: got %v, wanted %v", spec, got, want)
+		}
+	}
+}
+
+func TestPortBindings(t *testing.T) {
+	bindings := []PortBinding{
+		NewPortBinding("192.168.1.10", "8080"),
+		NewPortBinding("::", "32769"),
+		NewPortBinding("fe80::1", "8081"),
+		NewPortBinding("0.0.0.0", "32768"),
+	}
+	SortPortBindings(bindings)
+
+	want := []string{"127.0.0.1:32768", "[::1]:32769", "192.168.1.10:8080", "[fe80::1]:8081"}
+	for i, b := range bindings {
+		if b.Address() != want[i] {
+			t.Errorf("Unexpected address at %d: got %q, wanted %q", i, b.Address(), want[i])
+		}
+	}
+	if bindings[1].Family != "ipv6" {
+		t.Errorf("Unexpected family: got %q, wanted %q", bindings[1].Family, "ipv6")
+	}
+	if got := NewPortBinding("", "80").Address(); got != "127.0.0.1:80" {
+		t.Errorf("Unexpected address: got %q, wanted %q", got, "127.0.0.1:80")
+	}
+}
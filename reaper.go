@@ -0,0 +1,151 @@
+package podrick
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// SessionLabel is the label set on every container and network
+// created by podrick, with the ID of the session that created it.
+const SessionLabel = "podrick.session"
+
+// ReaperDisabledEnv is the environment variable used to disable
+// the reaper. The reaper is disabled if it is set to a true value,
+// as parsed by strconv.ParseBool.
+const ReaperDisabledEnv = "PODRICK_REAPER_DISABLED"
+
+// Environment variables used to configure a reaper process.
+const (
+	reaperSessionEnv = "PODRICK_REAPER_SESSION"
+	reaperRuntimeEnv = "PODRICK_REAPER_RUNTIME"
+)
+
+// reapTimeout limits the time a reaper spends
+// removing the resources of a session.
+const reapTimeout = time.Minute
+
+var sessionID = newSessionID()
+
+func newSessionID() string {
+	b := make([]byte, 8)
+	_, err := rand.Read(b)
+	if err != nil {
+		// Fall back to something unique to this process
+		return fmt.Sprintf("%x-%d", time.Now().UnixNano(), os.Getpid())
+	}
+	return hex.EncodeToString(b)
+}
+
+// SessionID returns the ID of the current session,
+// which is unique to the current process.
+func SessionID() string {
+	return sessionID
+}
+
+// ReaperEnabled reports whether the reaper has not been
+// disabled with the environment variable PODRICK_REAPER_DISABLED.
+func ReaperEnabled() bool {
+	disabled, _ := strconv.ParseBool(os.Getenv(ReaperDisabledEnv))
+	return !disabled
+}
+
+// UseReaper reports whether runtimes should start a reaper for
+// the container. The reaper can be disabled with WithReaper or
+// the environment variable PODRICK_REAPER_DISABLED.
+func (c *ContainerConfig) UseReaper() bool {
+	return !c.DisableReaper && ReaperEnabled()
+}
+
+var (
+	reapersMu sync.Mutex
+	reapers   = map[string]*os.File{}
+)
+
+// StartReaper starts a reaper for the runtime, if one has not
+// already been started by the current process. The reaper is a
+// copy of the current executable, which waits for the current
+// process to exit, and then removes all the resources labelled
+// with the ID of the session. This happens even if the process
+// panics or is killed, so resources are not leaked when Close
+// is not called. The runtime must call RunReaper in its init
+// function to handle running as a reaper.
+func StartReaper(runtime string) error {
+	reapersMu.Lock()
+	defer reapersMu.Unlock()
+	if reapers[runtime] != nil {
+		return nil
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to find executable: %w", err)
+	}
+	// The reaper detects that this process has exited when
+	// the write end of the pipe is closed by the OS.
+	r, w, err := os.Pipe()
+	if err != nil {
+		return fmt.Errorf("failed to create reaper pipe: %w", err)
+	}
+	defer r.Close()
+
+	cmd := exec.Command(exe)
+	cmd.Env = append(os.Environ(),
+		reaperSessionEnv+"="+sessionID,
+		reaperRuntimeEnv+"="+runtime,
+	)
+	cmd.ExtraFiles = []*os.File{r}
+	err = cmd.Start()
+	if err != nil {
+		w.Close()
+		return fmt.Errorf("failed to start reaper: %w", err)
+	}
+	err = cmd.Process.Release()
+	if err != nil {
+		w.Close()
+		return fmt.Errorf("failed to release reaper: %w", err)
+	}
+
+	// Keep a reference to the pipe, so it is
+	// not closed until the process exits.
+	reapers[runtime] = w
+	return nil
+}
+
+// RunReaper runs the reaper and exits the process, if the current
+// process is a reaper started by StartReaper for the runtime.
+// Otherwise, it returns immediately. Runtimes supporting reapers
+// must call it in their init function, with a function which
+// removes all the resources labelled with the session ID.
+func RunReaper(runtime string, reap func(ctx context.Context, session string) error) {
+	session := os.Getenv(reaperSessionEnv)
+	if session == "" || os.Getenv(reaperRuntimeEnv) != runtime {
+		return
+	}
+
+	// Survive signals sent to the process group of the parent
+	signal.Ignore(os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+
+	// Read until the parent exits and the pipe is closed
+	parent := os.NewFile(3, "reaper")
+	_, _ = io.Copy(ioutil.Discard, parent)
+
+	ctx, cancel := context.WithTimeout(context.Background(), reapTimeout)
+	err := reap(ctx, session)
+	cancel()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "podrick: failed to reap session %s: %v\n", session, err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
@@ -0,0 +1,70 @@
+package podrick
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func init() {
+	RunReaper("test", func(_ context.Context, session string) error {
+		return ioutil.WriteFile(os.Getenv("PODRICK_TEST_REAPED"), []byte(session), 0600)
+	})
+}
+
+func TestReaper(t *testing.T) {
+	if os.Getenv("PODRICK_TEST_REAPER_HELPER") == "1" {
+		err := StartReaper("test")
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Logf("session:%s", SessionID())
+		return
+	}
+
+	reaped := filepath.Join(t.TempDir(), "reaped")
+	cmd := exec.Command(os.Args[0], "-test.run=^TestReaper$", "-test.v")
+	cmd.Env = append(os.Environ(), "PODRICK_TEST_REAPER_HELPER=1", "PODRICK_TEST_REAPED="+reaped)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("Failed to run helper: %v: %s", err, out)
+	}
+	m := regexp.MustCompile(`session:(\w+)`).FindSubmatch(out)
+	if m == nil {
+		t.Fatalf("Failed to find session in helper output: %s", out)
+	}
+
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		session, err := ioutil.ReadFile(reaped)
+		if err == nil {
+			if string(session) != string(m[1]) {
+				t.Errorf("Unexpected session reaped: got %q, wanted %q", session, m[1])
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Reaper did not run after helper exited")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func TestUseReaper(t *testing.T) {
+	setEnv(t, ReaperDisabledEnv, "")
+	if !(&ContainerConfig{}).UseReaper() {
+		t.Error("Expected reaper to be enabled by default")
+	}
+	if (&ContainerConfig{DisableReaper: true}).UseReaper() {
+		t.Error("Expected reaper to be disabled by config")
+	}
+	setEnv(t, ReaperDisabledEnv, "true")
+	if (&ContainerConfig{}).UseReaper() {
+		t.Error("Expected reaper to be disabled by environment")
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"syscall"
+	"time"
 )
 
 // Runtime supports starting containers.
@@ -12,6 +14,33 @@ type Runtime interface {
 	Close(context.Context) error
 	Connect(context.Context) error
 	StartContainer(context.Context, *ContainerConfig) (Container, error)
+	// CreateNetwork creates a network with the name. Containers
+	// attached to the same network with WithNetwork can reach
+	// each other using their network aliases. Runtimes which
+	// don't support networks return an error.
+	CreateNetwork(ctx context.Context, name string) error
+	// RemoveNetwork removes the network with the name.
+	RemoveNetwork(ctx context.Context, name string) error
+	// BuildImage builds the image described by the config,
+	// and returns the ID of the built image.
+	BuildImage(ctx context.Context, conf BuildConfig) (string, error)
+}
+
+// ManagedRuntime is a Runtime which supports managing the containers
+// created by podrick, including those created by other processes.
+// It is implemented by the built-in runtimes, and used by the
+// podrick command.
+type ManagedRuntime interface {
+	Runtime
+	// ListContainers lists the containers created by podrick,
+	// including stopped containers.
+	ListContainers(context.Context) ([]ContainerInfo, error)
+	// RemoveContainer forcibly removes the container with the ID.
+	RemoveContainer(ctx context.Context, id string) error
+	// ContainerLogs writes the logs of the container with the ID to
+	// the writer. If follow is true, new logs are written until the
+	// container stops or the context is cancelled.
+	ContainerLogs(ctx context.Context, id string, follow bool, w io.Writer) error
 }
 
 // Container represents a running container.
@@ -19,18 +48,112 @@ type Container interface {
 	// Context releases resources associated with the container.
 	Close(context.Context) error
 	// Address returns the IP and port of the running container.
+	// Loopback addresses are preferred and wildcard addresses
+	// are translated to loopback addresses, so the address
+	// can be dialled directly.
 	Address() string
-	// AddressForPort returns the address for the specified port,
+	// AddressForPort returns the address for the specified TCP port,
 	// or an error, if the port was not exposed.
 	AddressForPort(string) (string, error)
+	// AddressForPortProto returns the address for the specified
+	// port and protocol, either tcp or udp, or an error, if the
+	// port was not exposed with the protocol.
+	AddressForPortProto(port, proto string) (string, error)
+	// Ports returns every host binding of each exposed port,
+	// with the binding used for the address of the port first.
+	Ports() map[Port][]PortBinding
+	// ID returns the ID of the container.
+	ID() string
+	// Name returns the name of the container.
+	Name() string
+	// IP returns the IP of the container on the network, for use
+	// by other containers. If the network is empty, the IP on the
+	// primary network of the container is returned. This is the
+	// first network the container was attached to, or the default
+	// network of the runtime.
+	IP(network string) (string, error)
+	// InternalAddress returns the address of the port on the
+	// primary network of the container, for use by other containers.
+	// The port does not need to be exposed.
+	InternalAddress(port string) (string, error)
 	// StreamLogs asynchronously streams logs from the
 	// running container to the writer. The writer must
 	// be safe for concurrent use.
 	// If the context is cancelled after logging has been set up,
-	// it has no effect. Use Close to stop logging.
+	// it has no effect. Use Close to stop logging. Logging also
+	// stops if the writer returns an error.
 	// This function is called automatically on the runtimes
 	// configured logger, so there is no need to explicitly call this.
 	StreamLogs(context.Context, io.Writer) error
+	// Exec runs the command inside the running container
+	// and waits for it to exit. The output of the command
+	// is captured in the result. A non-zero exit code
+	// is not considered an error.
+	Exec(ctx context.Context, cmd []string, opts ExecOptions) (ExecResult, error)
+	// Stop stops the running container. The container is
+	// killed if it has not stopped after the timeout, which
+	// is rounded up to whole seconds.
+	Stop(ctx context.Context, timeout time.Duration) error
+	// Start starts a stopped container. The addresses of
+	// the container are refreshed, since host ports may change.
+	// Logs streamed with StreamLogs are resumed.
+	Start(context.Context) error
+	// Restart restarts the container. The container is
+	// killed if it has not stopped after the timeout, which
+	// is rounded up to whole seconds. The addresses of the
+	// container are refreshed, since host ports may change.
+	// Logs streamed with StreamLogs are resumed.
+	Restart(ctx context.Context, timeout time.Duration) error
+	// Pause suspends all processes in the container.
+	Pause(context.Context) error
+	// Unpause resumes all processes in a paused container.
+	Unpause(context.Context) error
+	// Kill sends the signal to the main process of the container.
+	Kill(ctx context.Context, signal syscall.Signal) error
+	// Wait blocks until the container exits and returns
+	// its exit code. Any logs streamed with StreamLogs are
+	// fully written before Wait returns.
+	Wait(context.Context) (exitCode int, err error)
+	// HealthStatus returns the status of the healthcheck
+	// of the container.
+	HealthStatus(context.Context) (HealthStatus, error)
+	// CopyFrom returns a tar archive of the file or directory
+	// at the absolute path inside the container. Entries in the
+	// archive are named relative to the parent of the path.
+	// The caller must close the returned reader.
+	CopyFrom(ctx context.Context, path string) (io.ReadCloser, error)
+	// CopyTo writes the files to the running container,
+	// replacing any existing files at the same paths.
+	CopyTo(ctx context.Context, files ...File) error
+}
+
+// HealthStatus describes the status of the
+// healthcheck of a container.
+type HealthStatus string
+
+// Supported health statuses.
+const (
+	NoHealthcheck HealthStatus = "none"
+	Starting      HealthStatus = "starting"
+	Healthy       HealthStatus = "healthy"
+	Unhealthy     HealthStatus = "unhealthy"
+)
+
+// ContainerInfo describes a container created by podrick.
+type ContainerInfo struct {
+	ID      string
+	Name    string
+	Image   string
+	Created time.Time
+	// State is the state of the container as reported
+	// by the runtime, for example "running" or "exited".
+	State  string
+	Labels map[string]string
+}
+
+// Session returns the ID of the session that created the container.
+func (c ContainerInfo) Session() string {
+	return c.Labels[SessionLabel]
 }
 
 var autoRuntimes []Runtime
@@ -41,10 +164,57 @@ func RegisterAutoRuntime(r Runtime) {
 	autoRuntimes = append(autoRuntimes, r)
 }
 
+// AutoRuntime returns a Runtime which automatically
+// chooses a runtime from those registered when connecting.
+// This is the runtime used when one isn't explicitly specified.
+// The returned runtime implements ManagedRuntime, but its
+// methods fail if the chosen runtime does not.
+func AutoRuntime() Runtime {
+	return &autoRuntime{}
+}
+
 type autoRuntime struct {
 	Runtime
 }
 
+var _ ManagedRuntime = (*autoRuntime)(nil)
+
+// managed returns the chosen runtime as a ManagedRuntime.
+func (r *autoRuntime) managed() (ManagedRuntime, error) {
+	m, ok := r.Runtime.(ManagedRuntime)
+	if !ok {
+		return nil, fmt.Errorf("runtime %T does not support managing containers", r.Runtime)
+	}
+	return m, nil
+}
+
+// ListContainers lists the containers created by podrick.
+func (r *autoRuntime) ListContainers(ctx context.Context) ([]ContainerInfo, error) {
+	m, err := r.managed()
+	if err != nil {
+		return nil, err
+	}
+	return m.ListContainers(ctx)
+}
+
+// RemoveContainer forcibly removes the container with the ID.
+func (r *autoRuntime) RemoveContainer(ctx context.Context, id string) error {
+	m, err := r.managed()
+	if err != nil {
+		return err
+	}
+	return m.RemoveContainer(ctx, id)
+}
+
+// ContainerLogs writes the logs of the container with the ID to the writer.
+func (r *autoRuntime) ContainerLogs(ctx context.Context, id string, follow bool, w io.Writer) error {
+	m, err := r.managed()
+	if err != nil {
+		return err
+	}
+	return m.ContainerLogs(ctx, id, follow, w)
+}
+
 // Connect establishes a connection with the underlying runtime.
 func (r *autoRuntime) Connect(ctx context.Context) error {
 	if len(autoRuntimes) == 0 {
@@ -0,0 +1,60 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/pkg/jsonmessage"
+
+	"github.com/uw-labs/podrick"
+)
+
+// BuildImage builds an image with Docker.
+func (r *Runtime) BuildImage(ctx context.Context, conf podrick.BuildConfig) (string, error) {
+	buildCtx, err := conf.ContextArchive()
+	if err != nil {
+		return "", fmt.Errorf("failed to create build context: %w", err)
+	}
+	defer buildCtx.Close()
+	repo, tag := conf.Image()
+	buildArgs := make(map[string]*string, len(conf.BuildArgs))
+	for k, v := range conf.BuildArgs {
+		v := v
+		buildArgs[k] = &v
+	}
+
+	resp, err := r.client.ImageBuild(ctx, buildCtx, types.ImageBuildOptions{
+		Tags:        []string{repo + ":" + tag},
+		Dockerfile:  conf.Dockerfile,
+		BuildArgs:   buildArgs,
+		Target:      conf.Target,
+		Labels:      conf.Labels,
+		Remove:      true,
+		ForceRemove: true,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to build image: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var out io.Writer = ioutil.Discard
+	if conf.Output != nil {
+		out = conf.Output
+	}
+	var id string
+	err = jsonmessage.DisplayJSONMessagesStream(resp.Body, out, 0, false, func(msg jsonmessage.JSONMessage) {
+		var res types.BuildResult
+		if json.Unmarshal(*msg.Aux, &res) == nil && res.ID != "" {
+			id = res.ID
+		}
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to build image: %w", err)
+	}
+
+	return id, nil
+}
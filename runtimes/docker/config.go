@@ -4,28 +4,43 @@ import (
 	"strings"
 
 	ct "github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
 	"github.com/docker/docker/api/types/network"
 	"github.com/docker/go-connections/nat"
 	units "github.com/docker/go-units"
 	"github.com/uw-labs/podrick"
 )
 
-func createConfig(conf *podrick.ContainerConfig) (*ct.Config, *ct.HostConfig, *network.NetworkingConfig) {
+func createConfig(conf *podrick.ContainerConfig) (*ct.Config, *ct.HostConfig, *network.NetworkingConfig, error) {
 	dc := &ct.Config{
 		Image:        conf.Repo + ":" + conf.Tag,
 		Env:          conf.Env,
 		Cmd:          conf.Cmd,
-		ExposedPorts: nat.PortSet{nat.Port(conf.Port): struct{}{}},
-	}
-	for _, p := range conf.ExtraPorts {
-		dc.ExposedPorts[nat.Port(p)] = struct{}{}
+		ExposedPorts: nat.PortSet{},
+		Labels:       conf.AllLabels(),
 	}
 	if conf.Entrypoint != nil {
 		dc.Entrypoint = strings.Split(*conf.Entrypoint, " ")
 	}
 
 	hc := &ct.HostConfig{
+		// Ports without explicit bindings are published on random host ports
 		PublishAllPorts: true,
+		PortBindings:    nat.PortMap{},
+	}
+	specs, err := conf.PortSpecs()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	for _, spec := range specs {
+		port := portToDocker(spec)
+		dc.ExposedPorts[port] = struct{}{}
+		if spec.HostIP != "" || spec.HostPort != "" {
+			hc.PortBindings[port] = append(hc.PortBindings[port], nat.PortBinding{
+				HostIP:   spec.HostIP,
+				HostPort: spec.HostPort,
+			})
+		}
 	}
 	for _, ulimit := range conf.Ulimits {
 		hc.Ulimits = append(hc.Ulimits, &units.Ulimit{
@@ -35,6 +50,50 @@ func createConfig(conf *podrick.ContainerConfig) (*ct.Config, *ct.HostConfig, *n
 		})
 	}
 
+	for _, m := range conf.Mounts {
+		hc.Mounts = append(hc.Mounts, mountToDocker(m))
+	}
+
 	nc := &network.NetworkingConfig{}
-	return dc, hc, nc
+	// Only one network can be configured when creating the
+	// container, any others must be connected separately.
+	if len(conf.Networks) > 0 {
+		n := conf.Networks[0]
+		hc.NetworkMode = ct.NetworkMode(n.Name)
+		nc.EndpointsConfig = map[string]*network.EndpointSettings{
+			n.Name: {
+				Aliases: n.Aliases,
+			},
+		}
+	}
+	return dc, hc, nc, nil
+}
+
+func portToDocker(spec podrick.PortSpec) nat.Port {
+	return nat.Port(spec.Port().String())
+}
+
+func mountToDocker(m podrick.Mount) mount.Mount {
+	dm := mount.Mount{
+		Type:     mount.Type(m.Type),
+		Source:   m.Source,
+		Target:   m.Target,
+		ReadOnly: m.ReadOnly,
+	}
+	if m.Type == podrick.VolumeMount {
+		// The labels are only set if the volume is created
+		// by the mount, so the reaper can remove it.
+		dm.VolumeOptions = &mount.VolumeOptions{
+			Labels: podrick.DefaultLabels(),
+		}
+	}
+	if m.Type == podrick.TmpfsMount {
+		dm.Source = ""
+		if m.TmpfsSize > 0 {
+			dm.TmpfsOptions = &mount.TmpfsOptions{
+				SizeBytes: m.TmpfsSize,
+			}
+		}
+	}
+	return dm
 }
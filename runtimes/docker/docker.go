@@ -2,9 +2,12 @@ package docker
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"io"
 	"net"
+	"os"
+	"sort"
+	"strings"
 	"sync"
 
 	"github.com/docker/docker/api/types"
@@ -12,9 +15,11 @@ import (
 	"logur.dev/logur"
 
 	"github.com/uw-labs/podrick"
+	"github.com/uw-labs/podrick/runtimes/internal/logstream"
 )
 
 func init() {
+	podrick.RunReaper(reaperName, reap)
 	podrick.RegisterAutoRuntime(&Runtime{})
 }
 
@@ -26,62 +31,155 @@ func init() {
 // DOCKER_API_VERSION to set the version of the API to reach, leave empty for latest.
 // DOCKER_CERT_PATH to load the TLS certificates from.
 // DOCKER_TLS_VERIFY to enable or disable TLS verification, off by default.
+// PODRICK_HOST_OVERRIDE to set the host the addresses of containers resolve to.
+//
+// When DOCKER_HOST points at a remote daemon, the addresses of containers
+// resolve to its host. When running inside a container on the same daemon,
+// containers sharing a network with it are addressed by their internal IP.
 type Runtime struct {
 	Logger podrick.Logger
 
+	// mu guards connecting, so the
+	// runtime can be connected concurrently.
+	mu     sync.Mutex
 	client *docker.Client
+	// host is the host published ports are reachable on,
+	// or empty if they are reachable on the loopback address.
+	host string
+	// networks are the networks of the container the
+	// current process is running in, if any.
+	networks map[string]bool
 }
 
-// Connect connects to the Docker API.
+// Connect connects to the Docker API,
+// unless the runtime is already connected.
 func (r *Runtime) Connect(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.client != nil {
+		return nil
+	}
+
 	if r.Logger == nil {
 		r.Logger = logur.NewNoopLogger()
 	}
 
-	var err error
-	r.client, err = docker.NewClientWithOpts(docker.FromEnv, docker.WithAPIVersionNegotiation())
+	client, err := docker.NewClientWithOpts(docker.FromEnv, docker.WithAPIVersionNegotiation())
 	if err != nil {
 		return fmt.Errorf("failed to connect to docker: %w", err)
 	}
-	_, err = r.client.Ping(ctx)
+	_, err = client.Ping(ctx)
 	if err != nil {
+		cErr := client.Close()
+		if cErr != nil {
+			r.Logger.Error("failed to close client during error", map[string]interface{}{
+				"error": cErr.Error(),
+			})
+		}
 		return fmt.Errorf("failed to ping docker: %w", err)
 	}
+	r.client = client
+
+	r.host = podrick.RuntimeHost(r.client.DaemonHost())
+	if os.Getenv(podrick.HostOverrideEnv) == "" && podrick.InContainer() {
+		r.networks = r.ownNetworks(ctx)
+	}
 
 	return nil
 }
 
+// ownNetworks returns the networks of the container
+// the current process is running in, or nil if it
+// is not a container managed by the daemon.
+func (r *Runtime) ownNetworks(ctx context.Context) map[string]bool {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return nil
+	}
+	ctJSON, err := r.client.ContainerInspect(ctx, hostname)
+	if err != nil || ctJSON.NetworkSettings == nil {
+		r.Logger.Debug("running inside a container not managed by docker", map[string]interface{}{
+			"hostname": hostname,
+		})
+		return nil
+	}
+	networks := make(map[string]bool)
+	for name := range ctJSON.NetworkSettings.Networks {
+		networks[name] = true
+	}
+	return networks
+}
+
+// internalIP returns the IP of the container on a network
+// shared with the container the current process is running
+// in, or an empty string if there is none.
+func (r *Runtime) internalIP(ctJSON types.ContainerJSON) string {
+	var names []string
+	for name := range ctJSON.NetworkSettings.Networks {
+		if r.networks[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if ip := ctJSON.NetworkSettings.Networks[name].IPAddress; ip != "" {
+			return ip
+		}
+	}
+	return ""
+}
+
 // Close releases the resources of the Runtime.
-func (Runtime) Close(context.Context) error {
+func (*Runtime) Close(context.Context) error {
 	return nil
 }
 
 // StartContainer starts a container with Docker as the backing runtime.
-func (r *Runtime) StartContainer(ctx context.Context, conf *podrick.ContainerConfig) (podrick.Container, error) {
+func (r *Runtime) StartContainer(ctx context.Context, conf *podrick.ContainerConfig) (_ podrick.Container, err error) {
 	ctr := &container{
 		runtime: r,
 	}
-	_, _, err := r.client.ImageInspectWithRaw(ctx, conf.Repo+":"+conf.Tag)
+	ctr.logs = logstream.New(ctr.followLogs, r.Logger)
+	err = r.pullImage(ctx, conf)
 	if err != nil {
-		bd, err := r.client.ImagePull(ctx, conf.Repo+":"+conf.Tag, types.ImagePullOptions{})
-		if err != nil {
-			return nil, fmt.Errorf("failed to pull image: %w", err)
-		}
-		_, err = io.Copy(logur.NewWriter(r.Logger), bd)
-		if err != nil {
-			return nil, fmt.Errorf("failed to stream image: %w", err)
-		}
-		err = bd.Close()
-		if err != nil {
-			return nil, fmt.Errorf("failed to close pull body: %w", err)
-		}
+		return nil, err
+	}
+	if conf.UseReaper() {
+		r.startReaper()
 	}
 
-	cc, hc, nc := createConfig(conf)
+	cc, hc, nc, err := createConfig(conf)
+	if err != nil {
+		return nil, fmt.Errorf("invalid container config: %w", err)
+	}
 	resp, err := r.client.ContainerCreate(ctx, cc, hc, nc, "")
 	if err != nil {
 		return nil, fmt.Errorf("failed to create container: %w", err)
 	}
+	ctr.id = resp.ID
+	ctr.close = func(ctx context.Context) error {
+		return r.client.ContainerRemove(ctx, resp.ID, types.ContainerRemoveOptions{
+			RemoveVolumes: true,
+			Force:         true,
+		})
+	}
+	defer func() {
+		if err != nil {
+			cErr := ctr.Close(context.Background())
+			if cErr != nil {
+				r.Logger.Error("failed to close container during error", map[string]interface{}{
+					"error": cErr.Error(),
+				})
+			}
+		}
+	}()
+
+	if len(conf.Networks) > 1 {
+		err = r.connectNetworks(ctx, resp.ID, conf.Networks[1:]...)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	if len(conf.Files) > 0 {
 		err = uploadFiles(ctx, r.client, resp.ID, conf.Files...)
@@ -90,106 +188,174 @@ func (r *Runtime) StartContainer(ctx context.Context, conf *podrick.ContainerCon
 		}
 	}
 
+	if len(conf.Archives) > 0 {
+		err = uploadArchives(ctx, r.client, resp.ID, conf.Archives...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to upload archives to container: %w", err)
+		}
+	}
+
 	if err := r.client.ContainerStart(ctx, resp.ID, types.ContainerStartOptions{}); err != nil {
+		if portInUse(err) {
+			return nil, fmt.Errorf("failed to start container: %w: %v", podrick.ErrPortInUse, err)
+		}
 		return nil, fmt.Errorf("failed to start container: %w", err)
 	}
 
-	ctr.close = func(ctx context.Context) error {
-		return r.client.ContainerRemove(ctx, resp.ID, types.ContainerRemoveOptions{
-			RemoveVolumes: true,
-			Force:         true,
-		})
+	if conf.Port != "" {
+		specs, _ := conf.PortSpecs() // Already validated
+		ctr.port = specs[0].Port()
+	}
+	err = ctr.refresh(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	ctr.container, err = r.client.ContainerInspect(ctx, resp.ID)
+	return ctr, nil
+}
+
+type container struct {
+	id    string
+	port  podrick.Port
+	close func(context.Context) error
+
+	mu            sync.RWMutex
+	address       string
+	portToaddress map[podrick.Port]string
+	ports         map[podrick.Port][]podrick.PortBinding
+	container     types.ContainerJSON
+
+	logs    *logstream.Streams
+	runtime *Runtime
+}
+
+// refresh inspects the container and updates
+// the addresses of the exposed ports.
+func (c *container) refresh(ctx context.Context) error {
+	ctJSON, err := c.runtime.client.ContainerInspect(ctx, c.id)
 	if err != nil {
-		return nil, fmt.Errorf("failed to inspect container: %w", err)
+		return fmt.Errorf("failed to inspect container: %w", err)
 	}
 
-	if ctr.container.NetworkSettings == nil {
-		return nil, fmt.Errorf("failed to get container network")
+	if ctJSON.NetworkSettings == nil {
+		return fmt.Errorf("failed to get container network")
 	}
 
-	ctr.portToaddress = make(map[string]string)
-	for addr, hostPorts := range ctr.container.NetworkSettings.Ports {
-		for _, port := range hostPorts {
-			// Will use the last one, don't care for now
-			ctr.portToaddress[addr.Port()] = net.JoinHostPort(port.HostIP, port.HostPort)
+	ports := make(map[podrick.Port][]podrick.PortBinding)
+	for addr, hostPorts := range ctJSON.NetworkSettings.Ports {
+		port := podrick.Port{Number: addr.Port(), Protocol: addr.Proto()}
+		for _, hp := range hostPorts {
+			ports[port] = append(ports[port], podrick.NewPortBinding(hp.HostIP, hp.HostPort))
 		}
 	}
-
-	if ctr.portToaddress[conf.Port] == "" {
-		return nil, fmt.Errorf("failed to get container address")
+	portToaddress := make(map[podrick.Port]string)
+	for port, bindings := range ports {
+		if len(bindings) == 0 {
+			continue
+		}
+		podrick.SortPortBindings(bindings)
+		portToaddress[port] = bindings[0].HostAddress(c.runtime.host)
+	}
+	if ip := c.runtime.internalIP(ctJSON); ip != "" {
+		for addr := range ctJSON.NetworkSettings.Ports {
+			port := podrick.Port{Number: addr.Port(), Protocol: addr.Proto()}
+			portToaddress[port] = net.JoinHostPort(ip, port.Number)
+		}
 	}
 
-	ctr.address = ctr.portToaddress[conf.Port]
-	return ctr, nil
-}
-
-type container struct {
-	address       string
-	portToaddress map[string]string
-	close         func(context.Context) error
+	if c.port != (podrick.Port{}) && portToaddress[c.port] == "" {
+		return fmt.Errorf("failed to get container address")
+	}
 
-	container types.ContainerJSON
-	runtime   *Runtime
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.container = ctJSON
+	c.portToaddress = portToaddress
+	c.ports = ports
+	c.address = portToaddress[c.port]
+	return nil
 }
 
 func (c *container) Address() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
 	return c.address
 }
 
-func (c container) AddressForPort(port string) (string, error) {
-	hostPort, ok := c.portToaddress[port]
+func (c *container) AddressForPort(port string) (string, error) {
+	return c.AddressForPortProto(port, "tcp")
+}
+
+func (c *container) AddressForPortProto(port, proto string) (string, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	hostPort, ok := c.portToaddress[podrick.Port{Number: port, Protocol: proto}]
 	if !ok {
-		return "", fmt.Errorf("no address found for port %q", port)
+		return "", fmt.Errorf("no address found for port %q", port+"/"+proto)
 	}
 	return hostPort, nil
 }
 
-func (c *container) Close(ctx context.Context) error {
-	return c.close(ctx)
+func (c *container) Ports() map[podrick.Port][]podrick.PortBinding {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	ports := make(map[podrick.Port][]podrick.PortBinding, len(c.ports))
+	for port, bindings := range c.ports {
+		ports[port] = append([]podrick.PortBinding(nil), bindings...)
+	}
+	return ports
 }
 
-func (c *container) StreamLogs(_ context.Context, w io.Writer) error {
-	// Decoupled context from input context, since it controls logging lifetime.
-	ctx, cancel := context.WithCancel(context.Background())
-	body, err := c.runtime.client.ContainerLogs(ctx, c.container.ID, types.ContainerLogsOptions{
-		ShowStdout: true,
-		ShowStderr: true,
-		Follow:     true,
-		Timestamps: false,
-	})
-	if err != nil {
-		cancel()
-		return fmt.Errorf("failed to connect to container log output: %w", err)
-	}
+func (c *container) ID() string {
+	return c.id
+}
 
-	wg := &sync.WaitGroup{}
+func (c *container) Name() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return strings.TrimPrefix(c.container.Name, "/")
+}
 
-	cls := c.close
-	c.close = func(ctx context.Context) error {
-		cancel()
-		wg.Wait() // Wait for goroutine to exit
-		cErr := body.Close()
-		if cErr != nil {
-			c.runtime.Logger.Error("failed to close container logs", map[string]interface{}{
-				"error": cErr.Error(),
-			})
+func (c *container) IP(network string) (string, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	ns := c.container.NetworkSettings
+	if network == "" {
+		// The network mode is the name of the first network
+		// the container was attached to, unless it is the default.
+		if c.container.HostConfig != nil {
+			network = string(c.container.HostConfig.NetworkMode)
 		}
-		return cls(ctx)
+		if _, ok := ns.Networks[network]; !ok {
+			if ns.IPAddress != "" {
+				return ns.IPAddress, nil
+			}
+			return "", errors.New("container has no IP on its primary network")
+		}
+	}
+	ep, ok := ns.Networks[network]
+	if !ok || ep.IPAddress == "" {
+		return "", fmt.Errorf("container has no IP on network %q", network)
 	}
+	return ep.IPAddress, nil
+}
 
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		_, err := io.Copy(w, body)
-		if err != nil {
-			c.runtime.Logger.Error("failed to copy container logs", map[string]interface{}{
-				"error": err.Error(),
-			})
-		}
-	}()
+func (c *container) InternalAddress(port string) (string, error) {
+	ip, err := c.IP("")
+	if err != nil {
+		return "", err
+	}
+	return net.JoinHostPort(ip, port), nil
+}
 
-	return nil
+func (c *container) Close(ctx context.Context) error {
+	c.logs.Stop()
+	return c.close(ctx)
+}
+
+// portInUse reports whether the error was
+// caused by a host port already being in use.
+func portInUse(err error) bool {
+	return strings.Contains(err.Error(), "port is already allocated") ||
+		strings.Contains(err.Error(), "address already in use")
 }
@@ -0,0 +1,88 @@
+package docker
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/pkg/stdcopy"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/uw-labs/podrick"
+)
+
+func (c *container) Exec(ctx context.Context, cmd []string, opts podrick.ExecOptions) (podrick.ExecResult, error) {
+	exec, err := c.runtime.client.ContainerExecCreate(ctx, c.id, types.ExecConfig{
+		User:         opts.User,
+		AttachStdin:  opts.Stdin != nil,
+		AttachStdout: true,
+		AttachStderr: true,
+		Env:          opts.Env,
+		WorkingDir:   opts.WorkDir,
+		Cmd:          cmd,
+	})
+	if err != nil {
+		return podrick.ExecResult{}, fmt.Errorf("failed to create exec: %w", err)
+	}
+
+	resp, err := c.runtime.client.ContainerExecAttach(ctx, exec.ID, types.ExecStartCheck{})
+	if err != nil {
+		return podrick.ExecResult{}, fmt.Errorf("failed to attach to exec: %w", err)
+	}
+	defer resp.Close()
+
+	eg, egCtx := errgroup.WithContext(ctx)
+	done := make(chan struct{})
+	eg.Go(func() error {
+		// The hijacked connection does not respect the context,
+		// so close it if the context is cancelled.
+		select {
+		case <-egCtx.Done():
+			resp.Close()
+			return egCtx.Err()
+		case <-done:
+			return nil
+		}
+	})
+	if opts.Stdin != nil {
+		eg.Go(func() error {
+			_, err := io.Copy(resp.Conn, opts.Stdin)
+			if err != nil {
+				return fmt.Errorf("failed to write exec input: %w", err)
+			}
+			err = resp.CloseWrite()
+			if err != nil {
+				return fmt.Errorf("failed to close exec input: %w", err)
+			}
+			return nil
+		})
+	}
+
+	var stdout, stderr bytes.Buffer
+	eg.Go(func() error {
+		defer close(done)
+		_, err := stdcopy.StdCopy(&stdout, &stderr, resp.Reader)
+		if err != nil {
+			return fmt.Errorf("failed to read exec output: %w", err)
+		}
+		return nil
+	})
+
+	err = eg.Wait()
+	if err != nil {
+		return podrick.ExecResult{}, err
+	}
+
+	insp, err := c.runtime.client.ContainerExecInspect(ctx, exec.ID)
+	if err != nil {
+		return podrick.ExecResult{}, fmt.Errorf("failed to inspect exec: %w", err)
+	}
+
+	return podrick.ExecResult{
+		ExitCode: insp.ExitCode,
+		Stdout:   stdout.Bytes(),
+		Stderr:   stderr.Bytes(),
+	}, nil
+}
@@ -3,9 +3,12 @@ package docker
 import (
 	"archive/tar"
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"path"
 	"path/filepath"
+	"time"
 
 	"github.com/docker/docker/api/types"
 	docker "github.com/docker/docker/client"
@@ -14,27 +17,20 @@ import (
 )
 
 func uploadFiles(ctx context.Context, client *docker.Client, cID string, files ...podrick.File) error {
-	r, w := io.Pipe()
-
-	eg, ctx := errgroup.WithContext(ctx)
-	eg.Go(func() (err error) {
-		defer func() {
-			cErr := w.Close()
-			if err == nil {
-				err = cErr
-			}
-		}()
-
-		archive := tar.NewWriter(w)
+	return upload(ctx, client, cID, func(archive *tar.Writer) error {
 		for _, f := range files {
 			path := filepath.Clean(f.Path)
 			if !filepath.IsAbs(path) {
 				return fmt.Errorf("file paths must be absolute: %q", f.Path)
 			}
-			err = archive.WriteHeader(&tar.Header{
-				Name: f.Path,
-				Mode: int64(f.Mode),
-				Size: int64(f.Size),
+			err := archive.WriteHeader(&tar.Header{
+				Typeflag: tar.TypeReg,
+				Name:     f.Path,
+				Mode:     int64(f.Mode),
+				Size:     int64(f.Size),
+				Uid:      f.UID,
+				Gid:      f.GID,
+				ModTime:  time.Now(),
 			})
 			if err != nil {
 				return fmt.Errorf("failed to write file header: %w", err)
@@ -44,6 +40,72 @@ func uploadFiles(ctx context.Context, client *docker.Client, cID string, files .
 				return fmt.Errorf("failed to write file contents: %w", err)
 			}
 		}
+		return nil
+	})
+}
+
+func uploadArchives(ctx context.Context, client *docker.Client, cID string, archives ...podrick.Archive) error {
+	return upload(ctx, client, cID, func(archive *tar.Writer) error {
+		for _, a := range archives {
+			dir := filepath.Clean(a.Path)
+			if !filepath.IsAbs(dir) {
+				return fmt.Errorf("archive paths must be absolute: %q", a.Path)
+			}
+			// Docker requires the destination directory to exist,
+			// so root the entries at the destination instead.
+			tr := tar.NewReader(a.Content)
+			for {
+				hdr, err := tr.Next()
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				if err != nil {
+					return fmt.Errorf("failed to read archive: %w", err)
+				}
+				hdr.Name = rootEntry(dir, hdr.Name)
+				if hdr.Typeflag == tar.TypeDir {
+					hdr.Name += "/"
+				}
+				if hdr.Typeflag == tar.TypeLink {
+					hdr.Linkname = rootEntry(dir, hdr.Linkname)
+				}
+				err = archive.WriteHeader(hdr)
+				if err != nil {
+					return fmt.Errorf("failed to write file header: %w", err)
+				}
+				_, err = io.Copy(archive, tr)
+				if err != nil {
+					return fmt.Errorf("failed to write file contents: %w", err)
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// rootEntry returns the name of the archive entry rooted at dir.
+func rootEntry(dir, name string) string {
+	return path.Join(filepath.ToSlash(dir), path.Clean("/"+name))
+}
+
+// upload copies the tar archive written by write to the container.
+func upload(ctx context.Context, client *docker.Client, cID string, write func(*tar.Writer) error) error {
+	r, w := io.Pipe()
+
+	eg, ctx := errgroup.WithContext(ctx)
+	eg.Go(func() (err error) {
+		defer func() {
+			cErr := w.Close()
+			if err == nil {
+				err = cErr
+			}
+		}()
+
+		archive := tar.NewWriter(w)
+		err = write(archive)
+		if err != nil {
+			return err
+		}
 
 		err = archive.Close()
 		if err != nil {
@@ -76,3 +138,21 @@ func uploadFiles(ctx context.Context, client *docker.Client, cID string, files .
 
 	return nil
 }
+func (c *container) CopyFrom(ctx context.Context, path string) (io.ReadCloser, error) {
+	if !filepath.IsAbs(path) {
+		return nil, fmt.Errorf("file paths must be absolute: %q", path)
+	}
+	rc, _, err := c.runtime.client.CopyFromContainer(ctx, c.id, filepath.Clean(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy files from container: %w", err)
+	}
+	return rc, nil
+}
+
+func (c *container) CopyTo(ctx context.Context, files ...podrick.File) error {
+	err := uploadFiles(ctx, c.runtime.client, c.id, files...)
+	if err != nil {
+		return fmt.Errorf("failed to upload files to container: %w", err)
+	}
+	return nil
+}
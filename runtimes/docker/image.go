@@ -0,0 +1,104 @@
+package docker
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/docker/docker/api/types"
+	docker "github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/jsonmessage"
+	"logur.dev/logur"
+
+	"github.com/uw-labs/podrick"
+)
+
+// pullImage pulls the image of the container
+// according to its pull policy.
+func (r *Runtime) pullImage(ctx context.Context, conf *podrick.ContainerConfig) (err error) {
+	image := conf.Repo + ":" + conf.Tag
+	policy := conf.PullPolicy
+	if policy != podrick.PullAlways {
+		_, _, err = r.client.ImageInspectWithRaw(ctx, image)
+		if err == nil {
+			return nil
+		}
+		if !docker.IsErrNotFound(err) {
+			return fmt.Errorf("failed to inspect image: %w", err)
+		}
+		if policy == podrick.PullNever {
+			return fmt.Errorf("%w: %s", podrick.ErrImageNotPresent, image)
+		}
+	}
+
+	auth := conf.RegistryAuth
+	if auth == nil {
+		auth, err = podrick.LookupRegistryAuth(ctx, image)
+		if err != nil {
+			return fmt.Errorf("failed to look up registry credentials: %w", err)
+		}
+	}
+	opts := types.ImagePullOptions{}
+	if auth != nil {
+		opts.RegistryAuth, err = encodeAuth(podrick.RegistryHost(image), auth)
+		if err != nil {
+			return err
+		}
+	}
+
+	bd, err := r.client.ImagePull(ctx, image, opts)
+	if err != nil {
+		return fmt.Errorf("failed to pull image: %w", err)
+	}
+	defer func() {
+		cErr := bd.Close()
+		if err == nil && cErr != nil {
+			err = fmt.Errorf("failed to close pull body: %w", cErr)
+		}
+	}()
+	return displayPull(bd, logur.NewWriter(r.Logger))
+}
+
+// displayPull writes the progress of a pull to the writer.
+// Errors during the pull, such as failed authentication,
+// are reported in the stream rather than the response status,
+// and are returned.
+func displayPull(in io.Reader, out io.Writer) error {
+	dec := json.NewDecoder(in)
+	for {
+		var msg jsonmessage.JSONMessage
+		err := dec.Decode(&msg)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to decode pull output: %w", err)
+		}
+		// jsonmessage.DisplayJSONMessagesStream ignores
+		// messages with only the error string set.
+		if msg.Error == nil && msg.ErrorMessage != "" {
+			msg.Error = &jsonmessage.JSONError{Message: msg.ErrorMessage}
+		}
+		err = msg.Display(out, false)
+		if err != nil {
+			return fmt.Errorf("failed to pull image: %w", err)
+		}
+	}
+}
+
+// encodeAuth encodes the credentials for the registry
+// in the format expected by the Docker API.
+func encodeAuth(registry string, auth *podrick.RegistryAuth) (string, error) {
+	data, err := json.Marshal(types.AuthConfig{
+		Username:      auth.Username,
+		Password:      auth.Password,
+		IdentityToken: auth.IdentityToken,
+		ServerAddress: registry,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode registry credentials: %w", err)
+	}
+	return base64.URLEncoding.EncodeToString(data), nil
+}
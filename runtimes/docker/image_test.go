@@ -0,0 +1,113 @@
+package docker
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	docker "github.com/docker/docker/client"
+	"logur.dev/logur"
+
+	"github.com/uw-labs/podrick"
+)
+
+func TestPullImageAuth(t *testing.T) {
+	var auth types.AuthConfig
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/json"):
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message": "no such image"}`))
+		case strings.HasSuffix(r.URL.Path, "/images/create"):
+			data, err := base64.URLEncoding.DecodeString(r.Header.Get("X-Registry-Auth"))
+			if err == nil {
+				err = json.Unmarshal(data, &auth)
+			}
+			if err != nil {
+				t.Errorf("Failed to decode registry auth: %v", err)
+			}
+			_, _ = w.Write([]byte(`{"status": "Downloaded newer image"}`))
+		default:
+			t.Errorf("Unexpected request: %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	client, err := docker.NewClientWithOpts(docker.WithHost("tcp://"+srv.Listener.Addr().String()), docker.WithVersion("1.40"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &Runtime{
+		Logger: logur.NewNoopLogger(),
+		client: client,
+	}
+
+	err = r.pullImage(context.Background(), &podrick.ContainerConfig{
+		Repo: "registry.example.com:5000/app",
+		Tag:  "v1",
+		RegistryAuth: &podrick.RegistryAuth{
+			Username: "user",
+			Password: "s3cr3t",
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to pull image: %v", err)
+	}
+
+	want := types.AuthConfig{
+		Username:      "user",
+		Password:      "s3cr3t",
+		ServerAddress: "registry.example.com:5000",
+	}
+	if auth != want {
+		t.Errorf("Unexpected registry auth: got %+v, wanted %+v", auth, want)
+	}
+}
+
+func TestPullImageStreamError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/json"):
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message": "no such image"}`))
+		case strings.HasSuffix(r.URL.Path, "/images/create"):
+			// The daemon reports pull errors in the stream
+			_, _ = w.Write([]byte(`{"status": "Pulling from app"}` + "\n" + `{"error": "unauthorized"}` + "\n"))
+		default:
+			t.Errorf("Unexpected request: %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	client, err := docker.NewClientWithOpts(docker.WithHost("tcp://"+srv.Listener.Addr().String()), docker.WithVersion("1.40"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &Runtime{
+		Logger: logur.NewNoopLogger(),
+		client: client,
+	}
+
+	_, err = r.StartContainer(context.Background(), &podrick.ContainerConfig{
+		Repo: "registry.example.com:5000/app",
+		Tag:  "v1",
+		RegistryAuth: &podrick.RegistryAuth{
+			Username: "user",
+			Password: "wrong",
+		},
+		DisableReaper: true,
+	})
+	if err == nil {
+		t.Fatal("Expected error starting container")
+	}
+	if !strings.Contains(err.Error(), "unauthorized") {
+		t.Errorf("Expected pull error, got %v", err)
+	}
+}
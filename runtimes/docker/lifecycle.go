@@ -0,0 +1,106 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	ct "github.com/docker/docker/api/types/container"
+
+	"github.com/uw-labs/podrick"
+)
+
+// roundTimeout rounds the timeout up to whole seconds, since
+// docker rounds it to the nearest second, and kills the container
+// immediately if the timeout is zero.
+func roundTimeout(timeout time.Duration) *time.Duration {
+	t := time.Duration(math.Ceil(timeout.Seconds())) * time.Second
+	return &t
+}
+
+func (c *container) Stop(ctx context.Context, timeout time.Duration) error {
+	err := c.runtime.client.ContainerStop(ctx, c.id, roundTimeout(timeout))
+	if err != nil {
+		return fmt.Errorf("failed to stop container: %w", err)
+	}
+	return nil
+}
+
+func (c *container) Start(ctx context.Context) error {
+	err := c.runtime.client.ContainerStart(ctx, c.id, types.ContainerStartOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to start container: %w", err)
+	}
+	err = c.logs.Resume(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to resume container logs: %w", err)
+	}
+	return c.refresh(ctx)
+}
+
+func (c *container) Restart(ctx context.Context, timeout time.Duration) error {
+	err := c.runtime.client.ContainerRestart(ctx, c.id, roundTimeout(timeout))
+	if err != nil {
+		return fmt.Errorf("failed to restart container: %w", err)
+	}
+	err = c.logs.Resume(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to resume container logs: %w", err)
+	}
+	return c.refresh(ctx)
+}
+
+func (c *container) Pause(ctx context.Context) error {
+	err := c.runtime.client.ContainerPause(ctx, c.id)
+	if err != nil {
+		return fmt.Errorf("failed to pause container: %w", err)
+	}
+	return nil
+}
+
+func (c *container) Unpause(ctx context.Context) error {
+	err := c.runtime.client.ContainerUnpause(ctx, c.id)
+	if err != nil {
+		return fmt.Errorf("failed to unpause container: %w", err)
+	}
+	return nil
+}
+
+func (c *container) Kill(ctx context.Context, signal syscall.Signal) error {
+	err := c.runtime.client.ContainerKill(ctx, c.id, strconv.Itoa(int(signal)))
+	if err != nil {
+		return fmt.Errorf("failed to kill container: %w", err)
+	}
+	return nil
+}
+
+func (c *container) Wait(ctx context.Context) (int, error) {
+	statusC, errC := c.runtime.client.ContainerWait(ctx, c.id, ct.WaitConditionNotRunning)
+	var exitCode int
+	select {
+	case err := <-errC:
+		return 0, fmt.Errorf("failed to wait for container: %w", err)
+	case status := <-statusC:
+		if status.Error != nil {
+			return 0, fmt.Errorf("failed to wait for container: %s", status.Error.Message)
+		}
+		exitCode = int(status.StatusCode)
+	}
+
+	return exitCode, c.logs.Wait(ctx)
+}
+
+func (c *container) HealthStatus(ctx context.Context) (podrick.HealthStatus, error) {
+	ctJSON, err := c.runtime.client.ContainerInspect(ctx, c.id)
+	if err != nil {
+		return "", fmt.Errorf("failed to inspect container: %w", err)
+	}
+	if ctJSON.State == nil || ctJSON.State.Health == nil || ctJSON.State.Health.Status == "" {
+		return podrick.NoHealthcheck, nil
+	}
+	return podrick.HealthStatus(ctJSON.State.Health.Status), nil
+}
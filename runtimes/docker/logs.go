@@ -0,0 +1,41 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/pkg/stdcopy"
+)
+
+func (c *container) StreamLogs(ctx context.Context, w io.Writer) error {
+	return c.logs.Add(ctx, w)
+}
+
+// followLogs follows the logs of the container until
+// the container stops or the log context is cancelled.
+func (c *container) followLogs(_, logCtx context.Context) (func(io.Writer) error, error) {
+	body, err := c.runtime.client.ContainerLogs(logCtx, c.id, types.ContainerLogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Follow:     true,
+		Timestamps: false,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to container log output: %w", err)
+	}
+	return func(w io.Writer) error {
+		defer func() {
+			cErr := body.Close()
+			if cErr != nil {
+				c.runtime.Logger.Error("failed to close container logs", map[string]interface{}{
+					"error": cErr.Error(),
+				})
+			}
+		}()
+		// Logs of containers without a TTY are multiplexed.
+		_, err := stdcopy.StdCopy(w, w, body)
+		return err
+	}, nil
+}
@@ -0,0 +1,76 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/pkg/stdcopy"
+
+	"github.com/uw-labs/podrick"
+)
+
+var _ podrick.ManagedRuntime = (*Runtime)(nil)
+
+// ListContainers lists the containers created by podrick.
+func (r *Runtime) ListContainers(ctx context.Context) ([]podrick.ContainerInfo, error) {
+	ctrs, err := r.client.ContainerList(ctx, types.ContainerListOptions{
+		All:     true,
+		Filters: filters.NewArgs(filters.Arg("label", podrick.SessionLabel)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list containers: %w", err)
+	}
+	infos := make([]podrick.ContainerInfo, 0, len(ctrs))
+	for _, ctr := range ctrs {
+		var name string
+		if len(ctr.Names) > 0 {
+			name = strings.TrimPrefix(ctr.Names[0], "/")
+		}
+		infos = append(infos, podrick.ContainerInfo{
+			ID:      ctr.ID,
+			Name:    name,
+			Image:   ctr.Image,
+			Created: time.Unix(ctr.Created, 0),
+			State:   ctr.State,
+			Labels:  ctr.Labels,
+		})
+	}
+	return infos, nil
+}
+
+// RemoveContainer forcibly removes the container and its anonymous volumes.
+func (r *Runtime) RemoveContainer(ctx context.Context, id string) error {
+	err := r.client.ContainerRemove(ctx, id, types.ContainerRemoveOptions{
+		RemoveVolumes: true,
+		Force:         true,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to remove container: %w", err)
+	}
+	return nil
+}
+
+// ContainerLogs writes the logs of the container to the writer.
+func (r *Runtime) ContainerLogs(ctx context.Context, id string, follow bool, w io.Writer) error {
+	body, err := r.client.ContainerLogs(ctx, id, types.ContainerLogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Follow:     follow,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get container logs: %w", err)
+	}
+	defer body.Close()
+
+	// Logs of containers without a TTY are multiplexed.
+	_, err = stdcopy.StdCopy(w, w, body)
+	if err != nil {
+		return fmt.Errorf("failed to copy container logs: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/network"
+
+	"github.com/uw-labs/podrick"
+)
+
+// CreateNetwork creates a Docker bridge network.
+// The network is removed by the reaper, unless it is disabled.
+func (r *Runtime) CreateNetwork(ctx context.Context, name string) error {
+	if podrick.ReaperEnabled() {
+		r.startReaper()
+	}
+	_, err := r.client.NetworkCreate(ctx, name, types.NetworkCreate{
+		CheckDuplicate: true,
+		Labels:         podrick.DefaultLabels(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create network: %w", err)
+	}
+	return nil
+}
+
+// RemoveNetwork removes a Docker network.
+func (r *Runtime) RemoveNetwork(ctx context.Context, name string) error {
+	err := r.client.NetworkRemove(ctx, name)
+	if err != nil {
+		return fmt.Errorf("failed to remove network: %w", err)
+	}
+	return nil
+}
+
+// connectNetworks connects the container to the networks.
+func (r *Runtime) connectNetworks(ctx context.Context, cID string, networks ...podrick.NetworkAttachment) error {
+	for _, n := range networks {
+		err := r.client.NetworkConnect(ctx, n.Name, cID, &network.EndpointSettings{
+			Aliases: n.Aliases,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to connect container to network %q: %w", n.Name, err)
+		}
+	}
+	return nil
+}
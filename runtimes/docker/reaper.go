@@ -0,0 +1,80 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+
+	"github.com/uw-labs/podrick"
+)
+
+// reaperName identifies the Docker runtime to reapers.
+const reaperName = "docker"
+
+// startReaper starts the reaper for the session,
+// logging a warning if it fails to start.
+func (r *Runtime) startReaper() {
+	err := podrick.StartReaper(reaperName)
+	if err != nil {
+		r.Logger.Warn("failed to start reaper", map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
+}
+
+// reap removes the containers, networks and
+// volumes labelled with the session ID.
+func reap(ctx context.Context, session string) error {
+	r := &Runtime{}
+	err := r.Connect(ctx)
+	if err != nil {
+		return err
+	}
+	filter := filters.NewArgs(filters.Arg("label", podrick.SessionLabel+"="+session))
+
+	ctrs, err := r.client.ContainerList(ctx, types.ContainerListOptions{
+		All:     true,
+		Filters: filter,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list containers: %w", err)
+	}
+	var firstErr error
+	for _, ctr := range ctrs {
+		err = r.client.ContainerRemove(ctx, ctr.ID, types.ContainerRemoveOptions{
+			RemoveVolumes: true,
+			Force:         true,
+		})
+		if err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("failed to remove container: %w", err)
+		}
+	}
+
+	nets, err := r.client.NetworkList(ctx, types.NetworkListOptions{
+		Filters: filter,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list networks: %w", err)
+	}
+	for _, n := range nets {
+		err = r.client.NetworkRemove(ctx, n.ID)
+		if err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("failed to remove network: %w", err)
+		}
+	}
+
+	vols, err := r.client.VolumeList(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("failed to list volumes: %w", err)
+	}
+	for _, v := range vols.Volumes {
+		err = r.client.VolumeRemove(ctx, v.Name, true)
+		if err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("failed to remove volume: %w", err)
+		}
+	}
+
+	return firstErr
+}
@@ -0,0 +1,195 @@
+// Package logstream streams the logs of containers for the runtimes,
+// so the streams can be resumed when a container is started again.
+package logstream
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/uw-labs/podrick"
+)
+
+// Follow starts following the logs of a container from the start,
+// using ctx to set up. The logs are followed until the container stops
+// or logCtx is cancelled. The returned func is called in a goroutine to
+// copy the logs to the writer, and releases the resources of the logs
+// before returning.
+type Follow func(ctx, logCtx context.Context) (copyLogs func(w io.Writer) error, err error)
+
+// Streams are the log streams of a container.
+type Streams struct {
+	follow Follow
+	logger podrick.Logger
+
+	mu      sync.Mutex
+	streams []*stream
+}
+
+// New returns log streams following the logs with follow.
+// Errors copying the logs are logged to the logger.
+func New(follow Follow, logger podrick.Logger) *Streams {
+	return &Streams{
+		follow: follow,
+		logger: logger,
+	}
+}
+
+// stream streams the logs of a container to a writer.
+type stream struct {
+	w io.Writer
+	// skip is the number of bytes of the logs
+	// to skip before writing to the writer.
+	skip int64
+	// offset is the number of bytes of the logs
+	// consumed, including any skipped bytes.
+	offset int64
+	// stopped is set if the writer returned an error.
+	stopped bool
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func (s *stream) Write(p []byte) (int, error) {
+	n := len(p)
+	if s.skip > 0 {
+		k := int64(len(p))
+		if k > s.skip {
+			k = s.skip
+		}
+		p = p[k:]
+		s.skip -= k
+		s.offset += k
+	}
+	if len(p) == 0 {
+		return n, nil
+	}
+	m, err := s.w.Write(p)
+	s.offset += int64(m)
+	if err != nil {
+		s.stopped = true
+		return m, err
+	}
+	return n, nil
+}
+
+// Add streams the logs to the writer, until the container
+// stops, the streams are stopped or the writer errors.
+func (s *Streams) Add(ctx context.Context, w io.Writer) error {
+	st, err := s.start(ctx, w, 0)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.streams = append(s.streams, st)
+	return nil
+}
+
+// start streams the logs to the writer, starting at the offset.
+func (s *Streams) start(ctx context.Context, w io.Writer, offset int64) (*stream, error) {
+	// Decouple the lifetime of the stream from the input context
+	logCtx, cancel := context.WithCancel(context.Background())
+	copyLogs, err := s.follow(ctx, logCtx)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	st := &stream{
+		w:      w,
+		skip:   offset,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	go func() {
+		defer close(st.done)
+		defer cancel()
+		err := copyLogs(st)
+		switch {
+		case st.stopped:
+			s.remove(st)
+		case err != nil && logCtx.Err() == nil:
+			s.logger.Error("failed to copy container logs", map[string]interface{}{
+				"error": err.Error(),
+			})
+		}
+	}()
+
+	return st, nil
+}
+
+// remove removes a stream stopped by its writer,
+// so it is not resumed.
+func (s *Streams) remove(st *stream) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, ls := range s.streams {
+		if ls == st {
+			s.streams = append(s.streams[:i:i], s.streams[i+1:]...)
+			return
+		}
+	}
+}
+
+// Stop stops streaming logs and waits for the streams to exit.
+func (s *Streams) Stop() {
+	s.stop()
+}
+
+// stop stops the streams like Stop,
+// and returns the stopped streams.
+func (s *Streams) stop() []*stream {
+	s.mu.Lock()
+	streams := s.streams
+	s.streams = nil
+	s.mu.Unlock()
+	for _, st := range streams {
+		st.cancel()
+	}
+	for _, st := range streams {
+		<-st.done
+	}
+	return streams
+}
+
+// Resume resumes the streams where they stopped. The logs
+// are followed until the container stops, so they must be
+// resumed when it is started again. Resumed streams replay
+// the logs from the start, skipping what was already written.
+func (s *Streams) Resume(ctx context.Context) error {
+	var resumed []*stream
+	defer func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.streams = append(s.streams, resumed...)
+	}()
+	for _, st := range s.stop() {
+		if st.stopped {
+			continue
+		}
+		r, err := s.start(ctx, st.w, st.offset)
+		if err != nil {
+			return err
+		}
+		resumed = append(resumed, r)
+	}
+	return nil
+}
+
+// Wait waits for the streamed logs to be written.
+func (s *Streams) Wait(ctx context.Context) error {
+	s.mu.Lock()
+	streams := append([]*stream(nil), s.streams...)
+	s.mu.Unlock()
+	for _, st := range streams {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("failed to wait for container logs: %w", ctx.Err())
+		case <-st.done:
+		}
+	}
+	return nil
+}
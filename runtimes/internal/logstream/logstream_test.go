@@ -0,0 +1,101 @@
+package logstream
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"logur.dev/logur"
+)
+
+func TestStreamResume(t *testing.T) {
+	var out bytes.Buffer
+	s := &stream{w: &out}
+	_, _ = s.Write([]byte("first\nsec"))
+
+	// The resumed stream replays the logs from the start
+	r := &stream{w: &out, skip: s.offset}
+	for _, p := range []string{"fir", "st\nsecond\n", "third\n"} {
+		n, err := r.Write([]byte(p))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != len(p) {
+			t.Errorf("Unexpected write length: got %d, wanted %d", n, len(p))
+		}
+	}
+
+	if out.String() != "first\nsecond\nthird\n" {
+		t.Errorf("Unexpected logs: got %q, wanted %q", out.String(), "first\nsecond\nthird\n")
+	}
+	if r.offset != int64(out.Len()) {
+		t.Errorf("Unexpected offset: got %d, wanted %d", r.offset, out.Len())
+	}
+}
+
+type errWriter struct{}
+
+func (errWriter) Write([]byte) (int, error) {
+	return 0, errors.New("closed")
+}
+
+func TestStreams(t *testing.T) {
+	// logs are the logs of the container so far,
+	// which are replayed each time they are followed.
+	var (
+		mu   sync.Mutex
+		logs = "first\n"
+	)
+	follow := func(_, _ context.Context) (func(io.Writer) error, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		l := logs
+		return func(w io.Writer) error {
+			_, err := io.Copy(w, strings.NewReader(l))
+			return err
+		}, nil
+	}
+	s := New(follow, logur.NewNoopLogger())
+
+	var out bytes.Buffer
+	ctx := context.Background()
+	err := s.Add(ctx, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = s.Add(ctx, errWriter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = s.Wait(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	logs += "second\n"
+	mu.Unlock()
+	err = s.Resume(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = s.Wait(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "first\nsecond\n" {
+		t.Errorf("Unexpected logs: got %q, wanted %q", out.String(), "first\nsecond\n")
+	}
+
+	s.mu.Lock()
+	n := len(s.streams)
+	s.mu.Unlock()
+	if n != 1 {
+		t.Errorf("Expected stream stopped by its writer to be removed, got %d streams", n)
+	}
+	s.Stop()
+}
@@ -0,0 +1,144 @@
+package podman
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+
+	"github.com/varlink/go/varlink"
+
+	"github.com/uw-labs/podrick"
+	podman "github.com/uw-labs/podrick/runtimes/podman/iopodman"
+)
+
+// BuildImage builds an image with Podman. The build context is
+// sent to Podman as a tar archive. Build targets are not supported.
+func (r *Runtime) BuildImage(ctx context.Context, conf podrick.BuildConfig) (string, error) {
+	if conf.Target != "" {
+		return "", errors.New("build targets are not supported by podman")
+	}
+	buildCtx, err := conf.ContextArchive()
+	if err != nil {
+		return "", fmt.Errorf("failed to create build context: %w", err)
+	}
+	defer buildCtx.Close()
+	contextFile, err := r.sendFile(ctx, buildCtx)
+	if err != nil {
+		return "", fmt.Errorf("failed to send build context: %w", err)
+	}
+
+	repo, tag := conf.Image()
+	dockerfile := conf.Dockerfile
+	if dockerfile == "" {
+		dockerfile = "Dockerfile"
+	}
+	var labels []string
+	for k, v := range conf.Labels {
+		labels = append(labels, k+"="+v)
+	}
+	sort.Strings(labels)
+
+	buildC, closeConn, err := r.dedicatedConn(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer closeConn()
+	recv, err := podman.BuildImage().Send(ctx, buildC, varlink.More, podman.BuildInfo{
+		ContextDir:              contextFile,
+		Dockerfiles:             []string{dockerfile},
+		Output:                  repo + ":" + tag,
+		BuildArgs:               conf.BuildArgs,
+		Label:                   labels,
+		Layers:                  true,
+		RemoteIntermediateCtrs:  true,
+		ForceRmIntermediateCtrs: true,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to build image: %w", err)
+	}
+
+	var out io.Writer = ioutil.Discard
+	if conf.Output != nil {
+		out = conf.Output
+	}
+	var id string
+	for {
+		resp, flags, err := recv(ctx)
+		if err != nil {
+			return "", fmt.Errorf("failed to build image: %w", err)
+		}
+		for _, l := range resp.Logs {
+			_, err = io.WriteString(out, l)
+			if err != nil {
+				return "", fmt.Errorf("failed to write build output: %w", err)
+			}
+		}
+		if resp.Id != "" {
+			id = resp.Id
+		}
+		if flags&varlink.Continues == 0 {
+			break
+		}
+	}
+
+	return id, nil
+}
+
+// sendFile sends the content to Podman, which stores it
+// in a temporary file, and returns the path of the file.
+func (r *Runtime) sendFile(ctx context.Context, content io.Reader) (_ string, err error) {
+	// Podman needs the size of the file before it is sent,
+	// so the content is spooled to a temporary file.
+	f, err := ioutil.TempFile("", "podrick-")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer func() {
+		cErr := f.Close()
+		if rErr := os.Remove(f.Name()); cErr == nil {
+			cErr = rErr
+		}
+		if err == nil && cErr != nil {
+			err = fmt.Errorf("failed to remove temporary file: %w", cErr)
+		}
+	}()
+	size, err := io.Copy(f, content)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+	_, err = f.Seek(0, io.SeekStart)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+
+	sendC, closeConn, err := r.dedicatedConn(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer closeConn()
+
+	recv, err := podman.SendFile().Upgrade(ctx, sendC, "", size)
+	if err != nil {
+		return "", err
+	}
+	_, _, conn, err := recv(ctx)
+	if err != nil {
+		return "", err
+	}
+	_, err = io.Copy(ctxWriter{ctx: ctx, conn: conn}, f)
+	if err != nil {
+		return "", fmt.Errorf("failed to write file: %w", err)
+	}
+	// Podman acknowledges the file by writing its path followed by a colon
+	ack, err := conn.ReadBytes(ctx, ':')
+	if err != nil {
+		return "", fmt.Errorf("failed to read file acknowledgement: %w", err)
+	}
+
+	return string(bytes.TrimSuffix(ack, []byte(":"))), nil
+}
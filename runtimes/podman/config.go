@@ -1,13 +1,16 @@
 package podman
 
 import (
+	"fmt"
+	"sort"
 	"strconv"
+	"strings"
 
 	"github.com/uw-labs/podrick"
 	podman "github.com/uw-labs/podrick/runtimes/podman/iopodman"
 )
 
-func createConfig(conf *podrick.ContainerConfig) podman.Create {
+func createConfig(conf *podrick.ContainerConfig) (podman.Create, error) {
 	crt := podman.Create{
 		Args: append(
 			[]string{
@@ -15,10 +18,35 @@ func createConfig(conf *podrick.ContainerConfig) podman.Create {
 			},
 			conf.Cmd...,
 		),
-		Publish:    &[]string{conf.Port},
 		Entrypoint: conf.Entrypoint,
 	}
-	*crt.Publish = append(*crt.Publish, conf.ExtraPorts...)
+	switch {
+	case len(conf.Networks) > 1:
+		return podman.Create{}, fmt.Errorf("containers can only be attached to one network: %w", ErrNetworksNotSupported)
+	case len(conf.Networks) == 1:
+		if len(conf.Networks[0].Aliases) > 0 {
+			return podman.Create{}, fmt.Errorf("network aliases are not supported: %w", ErrNetworksNotSupported)
+		}
+		crt.Network = &conf.Networks[0].Name
+	}
+	var labels []string
+	for k, v := range conf.AllLabels() {
+		labels = append(labels, k+"="+v)
+	}
+	sort.Strings(labels)
+	crt.Label = &labels
+
+	specs, err := conf.PortSpecs()
+	if err != nil {
+		return podman.Create{}, err
+	}
+	var publish []string
+	for _, spec := range specs {
+		publish = append(publish, spec.String())
+	}
+	if len(publish) > 0 {
+		crt.Publish = &publish
+	}
 	if len(conf.Ulimits) > 0 {
 		var ulimits []string
 		for _, ulimit := range conf.Ulimits {
@@ -29,9 +57,48 @@ func createConfig(conf *podrick.ContainerConfig) podman.Create {
 	if len(conf.Env) > 0 {
 		crt.Env = &conf.Env
 	}
-	return crt
+	var volumes, tmpfs []string
+	for _, m := range conf.Mounts {
+		switch m.Type {
+		case podrick.TmpfsMount:
+			tmpfs = append(tmpfs, tmpfsToPodman(m))
+		default:
+			volumes = append(volumes, volumeToPodman(m))
+		}
+	}
+	if len(volumes) > 0 {
+		crt.Volume = &volumes
+	}
+	if len(tmpfs) > 0 {
+		crt.Tmpfs = &tmpfs
+	}
+	return crt, nil
 }
 
 func ulimitToPodman(u podrick.Ulimit) string {
 	return u.Name + "=" + strconv.Itoa(int(u.Soft)) + ":" + strconv.Itoa(int(u.Hard))
 }
+
+func volumeToPodman(m podrick.Mount) string {
+	if m.Source == "" {
+		// Anonymous volumes only take the target, and start
+		// empty, so there is no point mounting them read only.
+		return m.Target
+	}
+	v := m.Source + ":" + m.Target
+	if m.ReadOnly {
+		v += ":ro"
+	}
+	return v
+}
+
+func tmpfsToPodman(m podrick.Mount) string {
+	opts := []string{"rw"}
+	if m.ReadOnly {
+		opts[0] = "ro"
+	}
+	if m.TmpfsSize > 0 {
+		opts = append(opts, "size="+strconv.FormatInt(m.TmpfsSize, 10))
+	}
+	return m.Target + ":" + strings.Join(opts, ",")
+}
@@ -0,0 +1,50 @@
+package podman
+
+import (
+	"testing"
+
+	"github.com/uw-labs/podrick"
+)
+
+func TestVolumeToPodman(t *testing.T) {
+	tests := []struct {
+		name  string
+		mount podrick.Mount
+		want  string
+	}{
+		{
+			name:  "Bind mount",
+			mount: podrick.Mount{Type: podrick.BindMount, Source: "/host/data", Target: "/data"},
+			want:  "/host/data:/data",
+		},
+		{
+			name:  "Named volume",
+			mount: podrick.Mount{Type: podrick.VolumeMount, Source: "data", Target: "/data"},
+			want:  "data:/data",
+		},
+		{
+			name:  "Read only",
+			mount: podrick.Mount{Type: podrick.VolumeMount, Source: "data", Target: "/data", ReadOnly: true},
+			want:  "data:/data:ro",
+		},
+		{
+			name:  "Anonymous volume",
+			mount: podrick.Mount{Type: podrick.VolumeMount, Target: "/data"},
+			want:  "/data",
+		},
+		{
+			name:  "Read only anonymous volume",
+			mount: podrick.Mount{Type: podrick.VolumeMount, Target: "/data", ReadOnly: true},
+			want:  "/data",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			got := volumeToPodman(tt.mount)
+			if got != tt.want {
+				t.Errorf("Unexpected volume: got %q, wanted %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,172 @@
+package podman
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/varlink/go/varlink"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/uw-labs/podrick"
+	podman "github.com/uw-labs/podrick/runtimes/podman/iopodman"
+)
+
+// Stream identifiers used by podman to multiplex
+// data over an upgraded varlink connection.
+const (
+	toStdout byte = iota
+	toStdin
+	toStderr
+	terminalResize
+	quit
+	hangUpFromClient
+)
+
+func (c *container) Exec(ctx context.Context, cmd []string, opts podrick.ExecOptions) (_ podrick.ExecResult, err error) {
+	execC, closeConn, err := c.runtime.dedicatedConn(ctx)
+	if err != nil {
+		return podrick.ExecResult{}, err
+	}
+	defer closeConn()
+
+	execOpts := podman.ExecOpts{
+		Name: c.id,
+		Cmd:  cmd,
+	}
+	if opts.User != "" {
+		execOpts.User = &opts.User
+	}
+	if opts.WorkDir != "" {
+		execOpts.Workdir = &opts.WorkDir
+	}
+	if len(opts.Env) > 0 {
+		execOpts.Env = &opts.Env
+	}
+
+	recv, err := podman.ExecContainer().Upgrade(ctx, execC, execOpts)
+	if err != nil {
+		return podrick.ExecResult{}, fmt.Errorf("failed to exec in container: %w", err)
+	}
+	_, conn, err := recv(ctx)
+	if err != nil {
+		return podrick.ExecResult{}, fmt.Errorf("failed to exec in container: %w", err)
+	}
+
+	eg, egCtx := errgroup.WithContext(ctx)
+	if opts.Stdin != nil {
+		eg.Go(func() error {
+			err := writeStdin(egCtx, conn, opts.Stdin)
+			if err != nil {
+				return fmt.Errorf("failed to write exec input: %w", err)
+			}
+			return nil
+		})
+	}
+
+	var (
+		stdout, stderr bytes.Buffer
+		exitCode       int
+	)
+	eg.Go(func() (err error) {
+		exitCode, err = readOutput(egCtx, conn, &stdout, &stderr)
+		if err != nil {
+			return fmt.Errorf("failed to read exec output: %w", err)
+		}
+		return nil
+	})
+
+	err = eg.Wait()
+	if err != nil {
+		return podrick.ExecResult{}, err
+	}
+
+	return podrick.ExecResult{
+		ExitCode: exitCode,
+		Stdout:   stdout.Bytes(),
+		Stderr:   stderr.Bytes(),
+	}, nil
+}
+
+// writeFrame writes a single frame, consisting of an 8 byte
+// header containing the destination and the payload length,
+// followed by the payload.
+func writeFrame(ctx context.Context, conn varlink.ReadWriterContext, dest byte, payload []byte) error {
+	header := make([]byte, 8)
+	header[0] = dest
+	binary.BigEndian.PutUint32(header[4:], uint32(len(payload)))
+	_, err := conn.Write(ctx, append(header, payload...))
+	return err
+}
+
+func writeStdin(ctx context.Context, conn varlink.ReadWriterContext, stdin io.Reader) error {
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := stdin.Read(buf)
+		if n > 0 {
+			wErr := writeFrame(ctx, conn, toStdin, buf[:n])
+			if wErr != nil {
+				return wErr
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return writeFrame(ctx, conn, hangUpFromClient, nil)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func readOutput(ctx context.Context, conn varlink.ReadWriterContext, stdout, stderr io.Writer) (int, error) {
+	r := ctxReader{ctx: ctx, conn: conn}
+	header := make([]byte, 8)
+	for {
+		_, err := io.ReadFull(r, header)
+		if err != nil {
+			return 0, err
+		}
+		payload := make([]byte, binary.BigEndian.Uint32(header[4:]))
+		_, err = io.ReadFull(r, payload)
+		if err != nil {
+			return 0, err
+		}
+		switch header[0] {
+		case toStdout:
+			_, err = stdout.Write(payload)
+		case toStderr:
+			_, err = stderr.Write(payload)
+		case quit:
+			if len(payload) < 4 {
+				return 0, errors.New("missing exit code")
+			}
+			return int(binary.BigEndian.Uint32(payload)), nil
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+}
+
+// ctxReader adapts a varlink connection to an io.Reader.
+type ctxReader struct {
+	ctx  context.Context
+	conn varlink.ReadWriterContext
+}
+
+func (r ctxReader) Read(p []byte) (int, error) {
+	return r.conn.Read(r.ctx, p)
+}
+
+// ctxWriter adapts a varlink connection to an io.Writer.
+type ctxWriter struct {
+	ctx  context.Context
+	conn varlink.ReadWriterContext
+}
+
+func (w ctxWriter) Write(p []byte) (int, error) {
+	return w.conn.Write(w.ctx, p)
+}
@@ -1,12 +1,14 @@
 package podman
 
 import (
+	"archive/tar"
 	"context"
 	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/uw-labs/podrick"
 	podman "github.com/uw-labs/podrick/runtimes/podman/iopodman"
@@ -19,7 +21,7 @@ func uploadFiles(ctx context.Context, conn *varlink.Connection, cID string, file
 		return fmt.Errorf("failed to mount container filesystem: %w", err)
 	}
 	defer func() {
-		uErr := podman.UnmountContainer().Call(context.Background(), conn, cID, true)
+		uErr := unmountContainer(context.Background(), conn, cID)
 		if err == nil {
 			err = uErr
 		}
@@ -40,7 +42,14 @@ func uploadFile(ctx context.Context, mountDir string, file podrick.File) (err er
 	if !filepath.IsAbs(path) {
 		return fmt.Errorf("file paths must be absolute: %q", file.Path)
 	}
-	dest := filepath.Join(mountDir, path)
+	dest, err := resolveParent(mountDir, path)
+	if err != nil {
+		return fmt.Errorf("failed to resolve file path: %w", err)
+	}
+	err = removeNonDir(dest)
+	if err != nil {
+		return fmt.Errorf("failed to replace file: %w", err)
+	}
 	if _, err := os.Stat(filepath.Dir(dest)); errors.Is(err, os.ErrNotExist) {
 		err := os.MkdirAll(filepath.Dir(dest), 0777)
 		if err != nil {
@@ -67,5 +76,346 @@ func uploadFile(ctx context.Context, mountDir string, file podrick.File) (err er
 		return fmt.Errorf("failed to set file permissions: %w", err)
 	}
 
+	if file.UID != 0 || file.GID != 0 {
+		err = os.Chown(dest, file.UID, file.GID)
+		if err != nil {
+			return fmt.Errorf("failed to set file owner: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func uploadArchives(ctx context.Context, conn *varlink.Connection, cID string, archives ...podrick.Archive) (err error) {
+	mountDir, err := podman.MountContainer().Call(ctx, conn, cID)
+	if err != nil {
+		return fmt.Errorf("failed to mount container filesystem: %w", err)
+	}
+	defer func() {
+		uErr := unmountContainer(context.Background(), conn, cID)
+		if err == nil {
+			err = uErr
+		}
+	}()
+
+	for _, a := range archives {
+		err = extractArchive(mountDir, a)
+		if err != nil {
+			return fmt.Errorf("failed to extract archive: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func extractArchive(mountDir string, a podrick.Archive) error {
+	dir := filepath.Clean(a.Path)
+	if !filepath.IsAbs(dir) {
+		return fmt.Errorf("archive paths must be absolute: %q", a.Path)
+	}
+	root, err := resolveInRoot(mountDir, dir)
+	if err != nil {
+		return fmt.Errorf("failed to resolve directory: %w", err)
+	}
+	err = os.MkdirAll(root, 0755)
+	if err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	tr := tar.NewReader(a.Content)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read archive: %w", err)
+		}
+		// Cleaning the name as an absolute path ensures
+		// entries can't escape the destination.
+		name := filepath.Join(dir, filepath.Clean("/"+hdr.Name))
+		dest, err := resolveParent(mountDir, name)
+		if err != nil {
+			return fmt.Errorf("failed to resolve %q: %w", hdr.Name, err)
+		}
+		err = os.MkdirAll(filepath.Dir(dest), 0755)
+		if err != nil {
+			return fmt.Errorf("failed to create parent directory: %w", err)
+		}
+		mode := os.FileMode(hdr.Mode).Perm()
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			err = extractDir(dest, mode)
+		case tar.TypeReg:
+			err = extractFile(dest, mode, tr)
+		case tar.TypeSymlink:
+			err = removeNonDir(dest)
+			if err == nil {
+				err = os.Symlink(hdr.Linkname, dest)
+			}
+		case tar.TypeLink:
+			err = extractLink(mountDir, dest, filepath.Join(dir, filepath.Clean("/"+hdr.Linkname)))
+		default:
+			return fmt.Errorf("unsupported archive entry type %q for %q", hdr.Typeflag, hdr.Name)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to extract %q: %w", hdr.Name, err)
+		}
+		if hdr.Uid != 0 || hdr.Gid != 0 {
+			err = os.Lchown(dest, hdr.Uid, hdr.Gid)
+			if err != nil {
+				return fmt.Errorf("failed to set owner of %q: %w", hdr.Name, err)
+			}
+		}
+	}
+}
+
+// resolveInRoot joins the path to the root, resolving any symlinks
+// in the path as if the root were the filesystem root, so the result
+// can't be outside the root. The path does not need to exist.
+func resolveInRoot(root, path string) (string, error) {
+	// Linux limits the number of symlinks followed to 40
+	const maxLinks = 40
+	links := 0
+	resolved := "/"
+	remaining := path
+	for remaining != "" {
+		var part string
+		remaining = strings.TrimLeft(remaining, "/")
+		if i := strings.IndexByte(remaining, '/'); i >= 0 {
+			part, remaining = remaining[:i], remaining[i:]
+		} else {
+			part, remaining = remaining, ""
+		}
+		switch part {
+		case "", ".":
+			continue
+		case "..":
+			resolved = filepath.Dir(resolved)
+			continue
+		}
+
+		next := filepath.Join(resolved, part)
+		fi, err := os.Lstat(filepath.Join(root, next))
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				resolved = next
+				continue
+			}
+			return "", err
+		}
+		if fi.Mode()&os.ModeSymlink == 0 {
+			resolved = next
+			continue
+		}
+
+		links++
+		if links > maxLinks {
+			return "", fmt.Errorf("too many symlinks in %q", path)
+		}
+		link, err := os.Readlink(filepath.Join(root, next))
+		if err != nil {
+			return "", err
+		}
+		if filepath.IsAbs(link) {
+			resolved = "/"
+		}
+		remaining = link + "/" + remaining
+	}
+	return filepath.Join(root, resolved), nil
+}
+
+// resolveParent is like resolveInRoot, but does not resolve
+// the last element of the path, so it can be replaced.
+func resolveParent(root, path string) (string, error) {
+	parent, err := resolveInRoot(root, filepath.Dir(path))
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(parent, filepath.Base(path)), nil
+}
+
+// removeNonDir removes the file at the path, unless it is a directory,
+// so that it can be replaced without following any symlink at the path.
+func removeNonDir(path string) error {
+	fi, err := os.Lstat(path)
+	if errors.Is(err, os.ErrNotExist) || (err == nil && fi.IsDir()) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
+
+func extractDir(dest string, mode os.FileMode) error {
+	err := removeNonDir(dest)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(dest, mode)
+	if err != nil {
+		return err
+	}
+	return os.Chmod(dest, mode)
+}
+
+// extractLink creates a hard link to the target, which is
+// resolved inside the root like the other archive entries.
+func extractLink(root, dest, target string) error {
+	src, err := resolveInRoot(root, target)
+	if err != nil {
+		return err
+	}
+	err = removeNonDir(dest)
+	if err != nil {
+		return err
+	}
+	return os.Link(src, dest)
+}
+
+func extractFile(dest string, mode os.FileMode, r io.Reader) (err error) {
+	err = removeNonDir(dest)
+	if err != nil {
+		return err
+	}
+	target, err := os.OpenFile(dest, os.O_CREATE|os.O_EXCL|os.O_WRONLY, mode)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		cErr := target.Close()
+		if err == nil {
+			err = cErr
+		}
+	}()
+	_, err = io.Copy(target, r)
+	if err != nil {
+		return err
+	}
+	return os.Chmod(dest, mode)
+}
+
+func (c *container) CopyFrom(ctx context.Context, path string) (_ io.ReadCloser, err error) {
+	path = filepath.Clean(path)
+	if !filepath.IsAbs(path) {
+		return nil, fmt.Errorf("file paths must be absolute: %q", path)
+	}
+	mountDir, err := podman.MountContainer().Call(ctx, c.conn, c.id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to mount container filesystem: %w", err)
+	}
+
+	src, err := resolveParent(mountDir, path)
+	if err == nil {
+		_, err = os.Lstat(src)
+	}
+	if err != nil {
+		uErr := unmountContainer(context.Background(), c.conn, c.id)
+		if uErr != nil {
+			c.runtime.Logger.Error("failed to unmount container filesystem", map[string]interface{}{
+				"error": uErr.Error(),
+			})
+		}
+		return nil, fmt.Errorf("failed to stat file: %w", err)
+	}
+
+	r, w := io.Pipe()
+	go func() {
+		err := writeTar(w, src, filepath.Base(path))
+		uErr := c.unmountAsync()
+		if err == nil {
+			err = uErr
+		}
+		_ = w.CloseWithError(err)
+	}()
+
+	return r, nil
+}
+
+// unmountContainer unmounts the filesystem of the container. The mounts
+// are reference counted, so the filesystem stays mounted while it is
+// in use by any other copy.
+func unmountContainer(ctx context.Context, conn *varlink.Connection, cID string) error {
+	err := podman.UnmountContainer().Call(ctx, conn, cID, false)
+	if err != nil {
+		return fmt.Errorf("failed to unmount container filesystem: %w", err)
+	}
+	return nil
+}
+
+// unmountAsync unmounts the filesystem of the
+// container from a goroutine.
+func (c *container) unmountAsync() error {
+	conn, closeConn, err := c.runtime.dedicatedConn(context.Background())
+	if err != nil {
+		return err
+	}
+	defer closeConn()
+	return unmountContainer(context.Background(), conn, c.id)
+}
+
+// writeTar writes the file or directory at src to w as a
+// tar archive, with entries named relative to name.
+func writeTar(w io.Writer, src, name string) error {
+	archive := tar.NewWriter(w)
+	err := filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		var link string
+		if info.Mode()&os.ModeSymlink != 0 {
+			link, err = os.Readlink(path)
+			if err != nil {
+				return fmt.Errorf("failed to read symlink: %w", err)
+			}
+		}
+		hdr, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return fmt.Errorf("failed to create file header: %w", err)
+		}
+		hdr.Name = filepath.ToSlash(filepath.Join(name, rel))
+		if info.IsDir() {
+			hdr.Name += "/"
+		}
+		err = archive.WriteHeader(hdr)
+		if err != nil {
+			return fmt.Errorf("failed to write file header: %w", err)
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return fmt.Errorf("failed to open file: %w", err)
+		}
+		defer f.Close()
+		_, err = io.Copy(archive, f)
+		if err != nil {
+			return fmt.Errorf("failed to write file contents: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	err = archive.Close()
+	if err != nil {
+		return fmt.Errorf("failed to write tar footer: %w", err)
+	}
+
+	return nil
+}
+
+func (c *container) CopyTo(ctx context.Context, files ...podrick.File) error {
+	err := uploadFiles(ctx, c.conn, c.id, files...)
+	if err != nil {
+		return fmt.Errorf("failed to upload files to container: %w", err)
+	}
 	return nil
 }
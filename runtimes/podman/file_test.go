@@ -0,0 +1,86 @@
+package podman
+
+import (
+	"archive/tar"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/uw-labs/podrick"
+)
+
+func TestExtractArchive(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "podrick")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	mountDir := filepath.Join(tmp, "root")
+	outside := filepath.Join(tmp, "outside")
+	for _, dir := range []string{mountDir, outside} {
+		err = os.Mkdir(dir, 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	entries := []struct {
+		hdr     tar.Header
+		content string
+	}{
+		// Symlinks are resolved inside the mount directory
+		{hdr: tar.Header{Name: "abs", Typeflag: tar.TypeSymlink, Linkname: outside}},
+		{hdr: tar.Header{Name: "abs/x", Typeflag: tar.TypeReg, Mode: 0644}, content: "abs"},
+		{hdr: tar.Header{Name: "rel", Typeflag: tar.TypeSymlink, Linkname: "../../../outside"}},
+		{hdr: tar.Header{Name: "rel/y", Typeflag: tar.TypeReg, Mode: 0644}, content: "rel"},
+		{hdr: tar.Header{Name: "file", Typeflag: tar.TypeReg, Mode: 0644}, content: "file"},
+		{hdr: tar.Header{Name: "link", Typeflag: tar.TypeLink, Linkname: "file"}},
+	}
+	for _, e := range entries {
+		hdr := e.hdr
+		hdr.Size = int64(len(e.content))
+		err = tw.WriteHeader(&hdr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = tw.Write([]byte(e.content))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = tw.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = extractArchive(mountDir, podrick.Archive{Content: &buf, Path: "/app"})
+	if err != nil {
+		t.Fatalf("Failed to extract archive: %v", err)
+	}
+
+	escaped, err := ioutil.ReadDir(outside)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(escaped) > 0 {
+		t.Errorf("Archive was extracted outside the mount directory: %v", escaped[0].Name())
+	}
+	for path, want := range map[string]string{
+		filepath.Join(outside, "x"): "abs",
+		"/outside/y":                "rel",
+		"/app/link":                 "file",
+	} {
+		got, err := ioutil.ReadFile(filepath.Join(mountDir, path))
+		if err != nil {
+			t.Errorf("Failed to read %q: %v", path, err)
+			continue
+		}
+		if string(got) != want {
+			t.Errorf("Unexpected contents of %q: got %q, wanted %q", path, got, want)
+		}
+	}
+}
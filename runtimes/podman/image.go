@@ -0,0 +1,61 @@
+package podman
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/varlink/go/varlink"
+	"logur.dev/logur"
+
+	"github.com/uw-labs/podrick"
+	podman "github.com/uw-labs/podrick/runtimes/podman/iopodman"
+)
+
+// pullImage pulls the image of the container according to its
+// pull policy. The Podman varlink API does not accept registry
+// credentials, so images are pulled with the credentials
+// configured for the Podman service.
+func (r *Runtime) pullImage(ctx context.Context, conn *varlink.Connection, conf *podrick.ContainerConfig) error {
+	image := conf.Repo + ":" + conf.Tag
+	policy := conf.PullPolicy
+	if policy != podrick.PullAlways {
+		// 0 means the image exists, 1 that it does not
+		exists, err := podman.ImageExists().Call(ctx, conn, image)
+		if err != nil {
+			return fmt.Errorf("failed to check image exists: %w", err)
+		}
+		if exists == 0 {
+			return nil
+		}
+		if policy == podrick.PullNever {
+			return fmt.Errorf("%w: %s", podrick.ErrImageNotPresent, image)
+		}
+	}
+
+	if conf.RegistryAuth != nil {
+		r.Logger.Warn("registry credentials are not supported by podman, using the credentials of the podman service", map[string]interface{}{
+			"image": image,
+		})
+	}
+	recv, err := podman.PullImage().Send(ctx, conn, varlink.More, image)
+	if err != nil {
+		return fmt.Errorf("failed to pull image: %w", err)
+	}
+	logs := logur.NewWriter(r.Logger)
+	for {
+		resp, flags, err := recv(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to pull image: %w", err)
+		}
+		for _, l := range resp.Logs {
+			_, err = io.WriteString(logs, l)
+			if err != nil {
+				return fmt.Errorf("failed to stream image: %w", err)
+			}
+		}
+		if flags&varlink.Continues == 0 {
+			return nil
+		}
+	}
+}
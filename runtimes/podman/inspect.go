@@ -0,0 +1,37 @@
+package podman
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/varlink/go/varlink"
+
+	podman "github.com/uw-labs/podrick/runtimes/podman/iopodman"
+)
+
+// inspectData is the subset of the podman
+// container inspection used by the runtime.
+type inspectData struct {
+	State struct {
+		Healthcheck struct {
+			Status string
+		}
+	}
+	NetworkSettings struct {
+		IPAddress string
+	}
+}
+
+func inspectContainer(ctx context.Context, conn *varlink.Connection, id string) (*inspectData, error) {
+	data, err := podman.InspectContainer().Call(ctx, conn, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect container: %w", err)
+	}
+	var insp inspectData
+	err = json.Unmarshal([]byte(data), &insp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode container inspection: %w", err)
+	}
+	return &insp, nil
+}
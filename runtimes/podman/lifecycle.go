@@ -0,0 +1,105 @@
+package podman
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"syscall"
+	"time"
+
+	"github.com/uw-labs/podrick"
+	podman "github.com/uw-labs/podrick/runtimes/podman/iopodman"
+)
+
+// waitInterval is how often podman checks whether
+// a waited for container has exited.
+const waitInterval = 100 * time.Millisecond
+
+// timeoutSeconds rounds the timeout up to whole seconds,
+// since podman kills the container immediately if the
+// timeout is zero.
+func timeoutSeconds(timeout time.Duration) int64 {
+	return int64(math.Ceil(timeout.Seconds()))
+}
+
+func (c *container) Stop(ctx context.Context, timeout time.Duration) error {
+	_, err := podman.StopContainer().Call(ctx, c.conn, c.id, timeoutSeconds(timeout))
+	if err != nil {
+		return fmt.Errorf("failed to stop container: %w", err)
+	}
+	return nil
+}
+
+func (c *container) Start(ctx context.Context) error {
+	_, err := podman.StartContainer().Call(ctx, c.conn, c.id)
+	if err != nil {
+		return fmt.Errorf("failed to start container: %w", err)
+	}
+	err = c.logs.Resume(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to resume container logs: %w", err)
+	}
+	return c.refresh(ctx)
+}
+
+func (c *container) Restart(ctx context.Context, timeout time.Duration) error {
+	_, err := podman.RestartContainer().Call(ctx, c.conn, c.id, timeoutSeconds(timeout))
+	if err != nil {
+		return fmt.Errorf("failed to restart container: %w", err)
+	}
+	err = c.logs.Resume(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to resume container logs: %w", err)
+	}
+	return c.refresh(ctx)
+}
+
+func (c *container) Pause(ctx context.Context) error {
+	_, err := podman.PauseContainer().Call(ctx, c.conn, c.id)
+	if err != nil {
+		return fmt.Errorf("failed to pause container: %w", err)
+	}
+	return nil
+}
+
+func (c *container) Unpause(ctx context.Context) error {
+	_, err := podman.UnpauseContainer().Call(ctx, c.conn, c.id)
+	if err != nil {
+		return fmt.Errorf("failed to unpause container: %w", err)
+	}
+	return nil
+}
+
+func (c *container) Kill(ctx context.Context, signal syscall.Signal) error {
+	_, err := podman.KillContainer().Call(ctx, c.conn, c.id, int64(signal))
+	if err != nil {
+		return fmt.Errorf("failed to kill container: %w", err)
+	}
+	return nil
+}
+
+func (c *container) Wait(ctx context.Context) (int, error) {
+	waitC, closeConn, err := c.runtime.dedicatedConn(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer closeConn()
+
+	exitCode, err := podman.WaitContainer().Call(ctx, waitC, c.id, waitInterval.Milliseconds())
+	if err != nil {
+		return 0, fmt.Errorf("failed to wait for container: %w", err)
+	}
+
+	return int(exitCode), c.logs.Wait(ctx)
+}
+
+func (c *container) HealthStatus(ctx context.Context) (podrick.HealthStatus, error) {
+	insp, err := inspectContainer(ctx, c.conn, c.id)
+	if err != nil {
+		return "", err
+	}
+	if insp.State.Healthcheck.Status == "" {
+		return podrick.NoHealthcheck, nil
+	}
+	return podrick.HealthStatus(insp.State.Healthcheck.Status), nil
+}
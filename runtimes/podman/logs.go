@@ -0,0 +1,52 @@
+package podman
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/varlink/go/varlink"
+
+	podman "github.com/uw-labs/podrick/runtimes/podman/iopodman"
+)
+
+func (c *container) StreamLogs(ctx context.Context, w io.Writer) error {
+	return c.logs.Add(ctx, w)
+}
+
+// followLogs follows the logs of the container until
+// the container stops or the log context is cancelled.
+func (c *container) followLogs(ctx, logCtx context.Context) (func(io.Writer) error, error) {
+	logC, closeConn, err := c.runtime.dedicatedConn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	logFn, err := podman.GetContainerLogs().Send(logCtx, logC, varlink.More, c.id)
+	if err != nil {
+		closeConn()
+		return nil, fmt.Errorf("failed to get container logs: %w", err)
+	}
+	return func(w io.Writer) error {
+		defer closeConn()
+		return copyLogs(logCtx, w, logFn)
+	}, nil
+}
+
+// copyLogs writes the log lines received to the writer.
+func copyLogs(ctx context.Context, w io.Writer, logFn func(context.Context) ([]string, uint64, error)) error {
+	for {
+		lines, f, err := logFn(ctx)
+		if err != nil {
+			return err
+		}
+		for _, l := range lines {
+			_, err = io.WriteString(w, l)
+			if err != nil {
+				return err
+			}
+		}
+		if f&varlink.Continues == 0 {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package podman
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/varlink/go/varlink"
+
+	"github.com/uw-labs/podrick"
+	podman "github.com/uw-labs/podrick/runtimes/podman/iopodman"
+)
+
+var _ podrick.ManagedRuntime = (*Runtime)(nil)
+
+// ListContainers lists the containers created by podrick.
+func (r *Runtime) ListContainers(ctx context.Context) ([]podrick.ContainerInfo, error) {
+	ctrs, err := podman.ListContainers().Call(ctx, r.conn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list containers: %w", err)
+	}
+	var infos []podrick.ContainerInfo
+	for _, ctr := range ctrs {
+		if _, ok := ctr.Labels[podrick.SessionLabel]; !ok {
+			continue
+		}
+		// The creation time is zero if it can't be parsed
+		created, _ := time.Parse(time.RFC3339, ctr.Createdat)
+		infos = append(infos, podrick.ContainerInfo{
+			ID:      ctr.Id,
+			Name:    ctr.Names,
+			Image:   ctr.Image,
+			Created: created,
+			State:   ctr.Status,
+			Labels:  ctr.Labels,
+		})
+	}
+	return infos, nil
+}
+
+// RemoveContainer forcibly removes the container and its volumes.
+func (r *Runtime) RemoveContainer(ctx context.Context, id string) error {
+	_, err := podman.RemoveContainer().Call(ctx, r.conn, id, true, true)
+	if err != nil {
+		return fmt.Errorf("failed to remove container: %w", err)
+	}
+	return nil
+}
+
+// ContainerLogs writes the logs of the container to the writer.
+func (r *Runtime) ContainerLogs(ctx context.Context, id string, follow bool, w io.Writer) (err error) {
+	var flags uint64
+	conn := r.conn
+	if follow {
+		flags = varlink.More
+		var closeConn func()
+		conn, closeConn, err = r.dedicatedConn(ctx)
+		if err != nil {
+			return err
+		}
+		defer closeConn()
+	}
+
+	recv, err := podman.GetContainerLogs().Send(ctx, conn, flags, id)
+	if err != nil {
+		return fmt.Errorf("failed to get container logs: %w", err)
+	}
+	for {
+		lines, f, err := recv(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to get container logs: %w", err)
+		}
+		for _, l := range lines {
+			_, err = io.WriteString(w, l)
+			if err != nil {
+				return fmt.Errorf("failed to write container logs: %w", err)
+			}
+		}
+		if f&varlink.Continues == 0 {
+			return nil
+		}
+	}
+}
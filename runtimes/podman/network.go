@@ -0,0 +1,24 @@
+package podman
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrNetworksNotSupported is returned when creating networks, or
+// attaching containers to more than one network or with aliases,
+// since the Podman varlink API does not support it. Containers can
+// be attached to one existing CNI network, for example one created
+// with podman network create. Use a Pod to start containers which
+// need to reach each other on localhost.
+var ErrNetworksNotSupported = errors.New("networks are not supported by podman")
+
+// CreateNetwork returns ErrNetworksNotSupported.
+func (r *Runtime) CreateNetwork(ctx context.Context, name string) error {
+	return ErrNetworksNotSupported
+}
+
+// RemoveNetwork returns ErrNetworksNotSupported.
+func (r *Runtime) RemoveNetwork(ctx context.Context, name string) error {
+	return ErrNetworksNotSupported
+}
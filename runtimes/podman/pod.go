@@ -0,0 +1,192 @@
+package podman
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/varlink/go/varlink"
+	"logur.dev/logur"
+
+	"github.com/uw-labs/podrick"
+	podman "github.com/uw-labs/podrick/runtimes/podman/iopodman"
+)
+
+// Pod is a group of containers sharing a network namespace.
+// The containers in a pod can reach each other on localhost.
+type Pod struct {
+	id         string
+	infraID    string
+	containers []*container
+	conn       *varlink.Connection
+	closeConn  func()
+}
+
+// StartPod creates a pod and starts a container for each of the configs
+// inside it. The ports of all the containers are published by the pod,
+// so the containers must not expose the same ports. The logs of the
+// containers are logged at Info level to the Logger of the Runtime.
+// Connect must be called before StartPod.
+func (r *Runtime) StartPod(ctx context.Context, confs ...*podrick.ContainerConfig) (_ *Pod, err error) {
+	if len(confs) == 0 {
+		return nil, errors.New("at least one container config must be provided")
+	}
+
+	var publish []string
+	for _, conf := range confs {
+		if len(conf.Networks) > 0 {
+			return nil, fmt.Errorf("invalid container config: %w", ErrNetworksNotSupported)
+		}
+		specs, err := conf.PortSpecs()
+		if err != nil {
+			return nil, fmt.Errorf("invalid container config: %w", err)
+		}
+		for _, spec := range specs {
+			publish = append(publish, spec.String())
+		}
+	}
+
+	conn, closeConn, err := r.dedicatedConn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	pod := &Pod{
+		conn:      conn,
+		closeConn: closeConn,
+	}
+	pod.id, err = podman.CreatePod().Call(ctx, conn, podman.PodCreate{
+		Infra:   true,
+		Publish: publish,
+		Labels:  podrick.DefaultLabels(),
+	})
+	if err != nil {
+		closeConn()
+		return nil, fmt.Errorf("failed to create pod: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			cErr := pod.Close(context.Background())
+			if cErr != nil {
+				r.Logger.Error("failed to close pod during error", map[string]interface{}{
+					"error": cErr.Error(),
+				})
+			}
+		}
+	}()
+
+	info, err := podman.GetPod().Call(ctx, conn, pod.id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pod information: %w", err)
+	}
+	for _, ci := range info.Containersinfo {
+		if strings.HasSuffix(ci.Name, "-infra") {
+			pod.infraID = ci.Id
+		}
+	}
+	if pod.infraID == "" {
+		return nil, errors.New("failed to find pod infra container")
+	}
+
+	for _, conf := range confs {
+		crt, err := createConfig(conf)
+		if err != nil {
+			return nil, fmt.Errorf("invalid container config: %w", err)
+		}
+		// Ports are published by the pod
+		crt.Publish = nil
+		crt.Pod = &pod.id
+		ctr, err := r.createContainer(ctx, conf, crt)
+		if err != nil {
+			return nil, err
+		}
+		ctr.portsFrom = pod.infraID
+		pod.containers = append(pod.containers, ctr)
+	}
+
+	_, err = podman.StartPod().Call(ctx, conn, pod.id)
+	if err != nil {
+		if portInUse(err) {
+			return nil, fmt.Errorf("failed to start pod: %w: %v", podrick.ErrPortInUse, err)
+		}
+		return nil, fmt.Errorf("failed to start pod: %w", err)
+	}
+
+	for _, ctr := range pod.containers {
+		err = ctr.refresh(ctx)
+		if err != nil {
+			return nil, err
+		}
+		err = ctr.StreamLogs(ctx, logur.NewWriter(r.Logger))
+		if err != nil {
+			return nil, fmt.Errorf("failed to stream container logs: %w", err)
+		}
+	}
+
+	return pod, nil
+}
+
+// ID returns the ID of the pod.
+func (p *Pod) ID() string {
+	return p.id
+}
+
+// Containers returns the containers in the pod,
+// in the order their configs were provided.
+func (p *Pod) Containers() []podrick.Container {
+	ctrs := make([]podrick.Container, 0, len(p.containers))
+	for _, ctr := range p.containers {
+		ctrs = append(ctrs, ctr)
+	}
+	return ctrs
+}
+
+// AddressForPort returns the address for the specified TCP port,
+// or an error, if the port was not published by the pod.
+func (p *Pod) AddressForPort(port string) (string, error) {
+	return p.AddressForPortProto(port, "tcp")
+}
+
+// AddressForPortProto returns the address for the specified port
+// and protocol, or an error, if the port was not published by the pod.
+func (p *Pod) AddressForPortProto(port, proto string) (string, error) {
+	if len(p.containers) == 0 {
+		return "", fmt.Errorf("no address found for port %q", port+"/"+proto)
+	}
+	return p.containers[0].AddressForPortProto(port, proto)
+}
+
+// Ports returns every host binding of each port published by the pod.
+func (p *Pod) Ports() map[podrick.Port][]podrick.PortBinding {
+	if len(p.containers) == 0 {
+		return nil
+	}
+	return p.containers[0].Ports()
+}
+
+// Stats returns the resource usage of the containers in the pod.
+func (p *Pod) Stats(ctx context.Context) ([]podman.ContainerStats, error) {
+	_, stats, err := podman.GetPodStats().Call(ctx, p.conn, p.id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pod stats: %w", err)
+	}
+	return stats, nil
+}
+
+// Close stops and removes the pod and all its containers.
+// The pod is removed even if closing a container fails,
+// and the first error is returned.
+func (p *Pod) Close(ctx context.Context) (err error) {
+	for _, ctr := range p.containers {
+		cErr := ctr.Close(ctx)
+		if err == nil {
+			err = cErr
+		}
+	}
+	_, rErr := podman.RemovePod().Call(ctx, p.conn, p.id, true)
+	if err == nil && rErr != nil {
+		err = fmt.Errorf("failed to remove pod: %w", rErr)
+	}
+	p.closeConn()
+	return err
+}
@@ -2,20 +2,23 @@ package podman
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"io"
 	"net"
 	"os"
+	"strings"
 	"sync"
 
 	"github.com/varlink/go/varlink"
 	"logur.dev/logur"
 
 	"github.com/uw-labs/podrick"
+	"github.com/uw-labs/podrick/runtimes/internal/logstream"
 	podman "github.com/uw-labs/podrick/runtimes/podman/iopodman"
 )
 
 func init() {
+	podrick.RunReaper(reaperName, reap)
 	podrick.RegisterAutoRuntime(&Runtime{})
 }
 
@@ -24,16 +27,43 @@ func init() {
 //
 // The Podman API address can be configured using the environment variable
 // PODMAN_VARLINK_ADDRESS. It defaults to "unix:/run/podman/io.podman".
+// The host the addresses of containers resolve to can be set using the
+// environment variable PODRICK_HOST_OVERRIDE.
+//
+// When the API address is a remote tcp address, the addresses of
+// containers resolve to its host. When running inside a container on the
+// same host, containers are addressed by their internal IP.
+//
+// The connection to podman is shared by every call to Connect,
+// and closed when each of them has been paired with a call to Close.
+// Each container has a connection of its own, so containers can
+// be used concurrently.
 type Runtime struct {
 	Logger podrick.Logger
 
+	// mu guards connecting and closing the connection.
+	mu      sync.Mutex
+	refs    int
 	address string
 	conn    *varlink.Connection
-	close   func(context.Context) error
+	// host is the host published ports are reachable on,
+	// or empty if they are reachable on the loopback address.
+	host string
+	// internal is true if the current process is running
+	// in a container managed by the same podman instance.
+	internal bool
 }
 
-// Connect connects to the podman varlink API.
+// Connect connects to the podman varlink API,
+// unless the runtime is already connected.
 func (r *Runtime) Connect(ctx context.Context) (err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.refs > 0 {
+		r.refs++
+		return nil
+	}
+
 	if r.Logger == nil {
 		r.Logger = logur.NewNoopLogger()
 	}
@@ -47,14 +77,11 @@ func (r *Runtime) Connect(ctx context.Context) (err error) {
 	if err != nil {
 		return fmt.Errorf("failed to connect to podman: %w", err)
 	}
-	r.close = func(_ context.Context) error {
-		return r.conn.Close()
-	}
 	defer func() {
 		if err != nil {
-			cErr := r.Close(context.Background())
+			cErr := r.conn.Close()
 			if cErr != nil {
-				r.Logger.Error("failed to close runtime during error", map[string]interface{}{
+				r.Logger.Error("failed to close connection during error", map[string]interface{}{
 					"error": cErr.Error(),
 				})
 			}
@@ -66,28 +93,137 @@ func (r *Runtime) Connect(ctx context.Context) (err error) {
 		return fmt.Errorf("failed to ping podman: %w", err)
 	}
 
+	r.host = podrick.RuntimeHost(r.address)
+	r.internal = false
+	if os.Getenv(podrick.HostOverrideEnv) == "" && podrick.InContainer() {
+		r.internal = r.isOwnContainer(ctx)
+	}
+
+	r.refs = 1
 	return nil
 }
 
-// Close releases the resources of the Runtime.
-func (r *Runtime) Close(ctx context.Context) error {
-	return r.close(ctx)
+// isOwnContainer reports whether the current process is
+// running in a container managed by the podman instance.
+func (r *Runtime) isOwnContainer(ctx context.Context) bool {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return false
+	}
+	_, err = podman.GetContainer().Call(ctx, r.conn, hostname)
+	if err != nil {
+		r.Logger.Debug("running inside a container not managed by podman", map[string]interface{}{
+			"hostname": hostname,
+		})
+		return false
+	}
+	return true
+}
+
+// dedicatedConn opens a new connection to podman. A varlink
+// connection serves one call at a time, so calls which block,
+// stream or upgrade the connection, or which run concurrently
+// with other calls, can't use the shared connection of the
+// runtime. The returned func closes the connection.
+func (r *Runtime) dedicatedConn(ctx context.Context) (*varlink.Connection, func(), error) {
+	conn, err := varlink.NewConnection(ctx, r.address)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to connect to podman: %w", err)
+	}
+	closeConn := func() {
+		cErr := conn.Close()
+		if cErr != nil {
+			r.Logger.Error("failed to close podman connection", map[string]interface{}{
+				"error": cErr.Error(),
+			})
+		}
+	}
+	return conn, closeConn, nil
+}
+
+// Close releases the resources of the Runtime. The connection
+// is closed by the call to Close paired with the last Connect.
+func (r *Runtime) Close(context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.refs == 0 {
+		return nil
+	}
+	r.refs--
+	if r.refs > 0 {
+		return nil
+	}
+	err := r.conn.Close()
+	if err != nil {
+		return fmt.Errorf("failed to close podman connection: %w", err)
+	}
+	return nil
 }
 
 // StartContainer starts a container with Podman as the backing runtime.
 func (r *Runtime) StartContainer(ctx context.Context, conf *podrick.ContainerConfig) (_ podrick.Container, err error) {
+	crt, err := createConfig(conf)
+	if err != nil {
+		return nil, fmt.Errorf("invalid container config: %w", err)
+	}
+	ctr, err := r.createContainer(ctx, conf, crt)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			cErr := ctr.Close(context.Background())
+			if cErr != nil {
+				r.Logger.Error("failed to close container during error", map[string]interface{}{
+					"error": cErr.Error(),
+				})
+			}
+		}
+	}()
+
+	_, err = podman.StartContainer().Call(ctx, ctr.conn, ctr.id)
+	if err != nil {
+		if portInUse(err) {
+			return nil, fmt.Errorf("failed to start container: %w: %v", podrick.ErrPortInUse, err)
+		}
+		return nil, fmt.Errorf("failed to start container: %w", err)
+	}
+
+	err = ctr.refresh(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return ctr, nil
+}
+
+// createContainer creates the container and uploads any files,
+// but does not start it.
+func (r *Runtime) createContainer(ctx context.Context, conf *podrick.ContainerConfig, crt podman.Create) (_ *container, err error) {
 	ctr := &container{
 		runtime: r,
 	}
-	ctr.id, err = podman.CreateContainer().Call(ctx, r.conn, createConfig(conf))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create container: %w", err)
+	if len(conf.Networks) > 0 {
+		ctr.network = conf.Networks[0].Name
 	}
-	ctr.close = func(ctx context.Context) error {
-		_, rErr := podman.RemoveContainer().Call(ctx, r.conn, ctr.id, true, true)
-		if rErr != nil {
-			return fmt.Errorf("failed to remove container: %w", rErr)
+	ctr.logs = logstream.New(ctr.followLogs, r.Logger)
+	if conf.Port != "" {
+		specs, err := conf.PortSpecs()
+		if err != nil {
+			return nil, fmt.Errorf("invalid container config: %w", err)
 		}
+		ctr.port = specs[0].Port()
+	}
+
+	// Each container has its own connection, so
+	// containers can be used concurrently.
+	conn, closeConn, err := r.dedicatedConn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ctr.conn = conn
+	ctr.close = func(context.Context) error {
+		closeConn()
 		return nil
 	}
 	defer func() {
@@ -101,134 +237,231 @@ func (r *Runtime) StartContainer(ctx context.Context, conf *podrick.ContainerCon
 		}
 	}()
 
+	err = r.pullImage(ctx, conn, conf)
+	if err != nil {
+		return nil, err
+	}
+	if conf.UseReaper() {
+		r.startReaper()
+	}
+	err = r.createVolumes(ctx, conn, conf.Mounts)
+	if err != nil {
+		return nil, err
+	}
+	ctr.id, err = podman.CreateContainer().Call(ctx, conn, crt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create container: %w", err)
+	}
+	ctr.portsFrom = ctr.id
+	ctr.close = func(ctx context.Context) error {
+		defer closeConn()
+		_, rErr := podman.RemoveContainer().Call(ctx, conn, ctr.id, true, true)
+		if rErr != nil {
+			return fmt.Errorf("failed to remove container: %w", rErr)
+		}
+		return nil
+	}
+
 	if len(conf.Files) > 0 {
-		err = uploadFiles(ctx, r.conn, ctr.id, conf.Files...)
+		err = uploadFiles(ctx, conn, ctr.id, conf.Files...)
 		if err != nil {
 			return nil, fmt.Errorf("failed to upload files to container: %w", err)
 		}
 	}
 
-	_, err = podman.StartContainer().Call(ctx, r.conn, ctr.id)
-	if err != nil {
-		return nil, fmt.Errorf("failed to start container: %w", err)
+	if len(conf.Archives) > 0 {
+		err = uploadArchives(ctx, conn, ctr.id, conf.Archives...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to upload archives to container: %w", err)
+		}
 	}
 
-	ct, err := podman.GetContainer().Call(ctx, r.conn, ctr.id)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get container information: %w", err)
+	return ctr, nil
+}
+
+// createVolumes creates the named volumes of the mounts which
+// don't exist yet. Podman would otherwise create them without
+// labels, so the reaper could not remove them.
+func (r *Runtime) createVolumes(ctx context.Context, conn *varlink.Connection, mounts []podrick.Mount) error {
+	var exists map[string]bool
+	for _, m := range mounts {
+		if m.Type != podrick.VolumeMount || m.Source == "" {
+			continue
+		}
+		if exists == nil {
+			vols, err := podman.GetVolumes().Call(ctx, conn, nil, true)
+			if err != nil {
+				return fmt.Errorf("failed to list volumes: %w", err)
+			}
+			exists = make(map[string]bool, len(vols))
+			for _, v := range vols {
+				exists[v.Name] = true
+			}
+		}
+		if exists[m.Source] {
+			continue
+		}
+		_, err := podman.VolumeCreate().Call(ctx, conn, podman.VolumeCreateOpts{
+			VolumeName: m.Source,
+			Labels:     podrick.DefaultLabels(),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create volume: %w", err)
+		}
+		exists[m.Source] = true
 	}
+	return nil
+}
 
-	ctr.portToaddress = make(map[string]string)
+type container struct {
+	id    string
+	port  podrick.Port
+	conn  *varlink.Connection
+	close func(context.Context) error
+	// portsFrom is the ID of the container publishing the
+	// ports of this container. This is the infra container
+	// for containers in a pod.
+	portsFrom string
+	// network is the network the container was
+	// attached to, or empty for the default network.
+	network string
+
+	mu            sync.RWMutex
+	address       string
+	portToaddress map[podrick.Port]string
+	ports         map[podrick.Port][]podrick.PortBinding
+	name          string
+	ip            string
 
+	logs    *logstream.Streams
+	runtime *Runtime
+}
+
+// refresh gets the container information and updates
+// the addresses of the exposed ports.
+func (c *container) refresh(ctx context.Context) error {
+	ct, err := podman.GetContainer().Call(ctx, c.conn, c.portsFrom)
+	if err != nil {
+		return fmt.Errorf("failed to get container information: %w", err)
+	}
+
+	ports := make(map[podrick.Port][]podrick.PortBinding)
 	for _, p := range ct.Ports {
-		ctr.portToaddress[p.Container_port] = net.JoinHostPort(p.Host_ip, p.Host_port)
+		proto := strings.ToLower(p.Protocol)
+		if proto == "" {
+			proto = "tcp"
+		}
+		port := podrick.Port{Number: p.Container_port, Protocol: proto}
+		ports[port] = append(ports[port], podrick.NewPortBinding(p.Host_ip, p.Host_port))
 	}
-	if ctr.portToaddress[conf.Port] == "" {
-		return nil, fmt.Errorf("failed to get container address")
+	portToaddress := make(map[podrick.Port]string)
+	for port, bindings := range ports {
+		podrick.SortPortBindings(bindings)
+		portToaddress[port] = bindings[0].HostAddress(c.runtime.host)
 	}
 
-	ctr.address = ctr.portToaddress[conf.Port]
-
-	return ctr, nil
-}
+	// Containers in a pod share the network of the infra container
+	insp, err := inspectContainer(ctx, c.conn, c.portsFrom)
+	if err != nil {
+		return err
+	}
+	ip := insp.NetworkSettings.IPAddress
+	if c.runtime.internal && ip != "" {
+		for port := range ports {
+			portToaddress[port] = net.JoinHostPort(ip, port.Number)
+		}
+	}
 
-type container struct {
-	address       string
-	portToaddress map[string]string
-	id            string
-	close         func(context.Context) error
+	self := ct
+	if c.portsFrom != c.id {
+		self, err = podman.GetContainer().Call(ctx, c.conn, c.id)
+		if err != nil {
+			return fmt.Errorf("failed to get container information: %w", err)
+		}
+	}
+	if c.port != (podrick.Port{}) && portToaddress[c.port] == "" {
+		return fmt.Errorf("failed to get container address")
+	}
 
-	runtime *Runtime
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.portToaddress = portToaddress
+	c.ports = ports
+	c.address = portToaddress[c.port]
+	c.name = self.Names
+	c.ip = ip
+	return nil
 }
 
-func (c container) Address() string {
+func (c *container) Address() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
 	return c.address
 }
 
-func (c container) AddressForPort(port string) (string, error) {
-	hostPort, ok := c.portToaddress[port]
+func (c *container) AddressForPort(port string) (string, error) {
+	return c.AddressForPortProto(port, "tcp")
+}
+
+func (c *container) AddressForPortProto(port, proto string) (string, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	hostPort, ok := c.portToaddress[podrick.Port{Number: port, Protocol: proto}]
 	if !ok {
-		return "", fmt.Errorf("no address found for port %q", port)
+		return "", fmt.Errorf("no address found for port %q", port+"/"+proto)
 	}
 	return hostPort, nil
 }
 
-func (c *container) Close(ctx context.Context) error {
-	return c.close(ctx)
+func (c *container) Ports() map[podrick.Port][]podrick.PortBinding {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	ports := make(map[podrick.Port][]podrick.PortBinding, len(c.ports))
+	for port, bindings := range c.ports {
+		ports[port] = append([]podrick.PortBinding(nil), bindings...)
+	}
+	return ports
 }
 
-func (c *container) StreamLogs(ctx context.Context, w io.Writer) (err error) {
-	logC, err := varlink.NewConnection(ctx, c.runtime.address)
-	if err != nil {
-		return fmt.Errorf("failed to get log connection: %w", err)
+func (c *container) ID() string {
+	return c.id
+}
+
+func (c *container) Name() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.name
+}
+
+// IP returns the IP of the container. Containers are attached to
+// one network, so only the IP on that network can be returned.
+func (c *container) IP(network string) (string, error) {
+	if network != "" && network != c.network {
+		return "", fmt.Errorf("container is not attached to network %q", network)
 	}
-	cls2 := c.close
-	c.close = func(ctx context.Context) error {
-		cErr := logC.Close()
-		if cErr != nil {
-			c.runtime.Logger.Error("failed to close logger connection", map[string]interface{}{
-				"error": cErr.Error(),
-			})
-		}
-		return cls2(ctx)
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.ip == "" {
+		return "", errors.New("container has no IP")
 	}
-	defer func() {
-		if err != nil {
-			cErr := c.Close(context.Background())
-			if cErr != nil {
-				c.runtime.Logger.Error("failed to close container during error", map[string]interface{}{
-					"error": cErr.Error(),
-				})
-			}
-		}
-	}()
+	return c.ip, nil
+}
 
-	logFn, err := podman.GetContainerLogs().Send(ctx, logC, varlink.More, c.id)
+func (c *container) InternalAddress(port string) (string, error) {
+	ip, err := c.IP("")
 	if err != nil {
-		return fmt.Errorf("Failed get container logs: %w", err)
+		return "", err
 	}
+	return net.JoinHostPort(ip, port), nil
+}
 
-	// Decouple lifetime of goroutine from input context
-	ctx, cancel := context.WithCancel(context.Background())
-	wg := &sync.WaitGroup{}
-
-	cls3 := c.close
-	c.close = func(ctx context.Context) error {
-		cancel()
-		// Ensure goroutine has exited
-		wg.Wait()
-		return cls3(ctx)
-	}
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		for {
-			select {
-			case <-ctx.Done():
-				return
-			default:
-			}
-			lines, f, err := logFn(ctx)
-			if err != nil {
-				c.runtime.Logger.Error("failed to get container logs", map[string]interface{}{
-					"error": err.Error(),
-				})
-				return
-			}
-			for _, l := range lines {
-				_, err = w.Write([]byte(l))
-				if err != nil {
-					c.runtime.Logger.Error("failed to write container logs", map[string]interface{}{
-						"error": err.Error(),
-					})
-					return
-				}
-			}
-			if f&varlink.Continues == 0 {
-				return
-			}
-		}
-	}()
+func (c *container) Close(ctx context.Context) error {
+	c.logs.Stop()
+	return c.close(ctx)
+}
 
-	return nil
+// portInUse reports whether the error was
+// caused by a host port already being in use.
+func portInUse(err error) bool {
+	return strings.Contains(err.Error(), "address already in use")
 }
@@ -1,15 +1,33 @@
 package podman_test
 
 import (
+	"archive/tar"
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
 	"net/http"
 	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
 	"testing"
+	"testing/fstest"
+	"time"
 
+	backoff "github.com/cenkalti/backoff/v3"
 	"github.com/uw-labs/podrick"
-	_ "github.com/uw-labs/podrick/runtimes/podman" // Register auto-runtime
+	"github.com/uw-labs/podrick/podricktest"
+	"github.com/uw-labs/podrick/runtimes/podman"
+	iopodman "github.com/uw-labs/podrick/runtimes/podman/iopodman"
+	"github.com/varlink/go/varlink"
 )
 
 type jsonResp struct {
@@ -38,7 +56,7 @@ func TestHTTPBin(t *testing.T) {
 		return err
 	}
 	ctr, err := podrick.StartContainer(ctx, "docker.io/kennethreitz/httpbin", "latest", "80",
-		podrick.WithLogger((*testLogger)(t)),
+		podrick.WithLogger(podricktest.Logger(t)),
 		podrick.WithLivenessCheck(lc),
 	)
 	if err != nil {
@@ -77,59 +95,886 @@ func TestHTTPBin(t *testing.T) {
 	}
 }
 
-type testLogger testing.T
+func TestExec(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ctr, err := podrick.StartContainer(ctx, "docker.io/kennethreitz/httpbin", "latest", "80",
+		podrick.WithLogger(podricktest.Logger(t)),
+	)
+	if err != nil {
+		t.Fatalf("Failed to start container: %v", err)
+	}
+	defer func() {
+		cErr := ctr.Close(context.Background())
+		if cErr != nil {
+			t.Fatal(cErr)
+		}
+	}()
+
+	res, err := ctr.Exec(ctx, []string{"sh", "-c", "cat; echo $GREETING >&2; exit 3"}, podrick.ExecOptions{
+		Stdin: strings.NewReader("hello"),
+		Env:   []string{"GREETING=world"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to exec in container: %v", err)
+	}
+
+	if res.ExitCode != 3 {
+		t.Errorf("Unexpected exit code: got %d, wanted %d", res.ExitCode, 3)
+	}
+	if string(res.Stdout) != "hello" {
+		t.Errorf("Unexpected stdout: got %q, wanted %q", res.Stdout, "hello")
+	}
+	if string(res.Stderr) != "world\n" {
+		t.Errorf("Unexpected stderr: got %q, wanted %q", res.Stderr, "world\n")
+	}
+}
+
+func TestStopStart(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	lc := func(address string) error {
+		_, err := http.Get("http://" + address + "/get")
+		return err
+	}
+	ctr, err := podrick.StartContainer(ctx, "docker.io/kennethreitz/httpbin", "latest", "80",
+		podrick.WithLogger(podricktest.Logger(t)),
+		podrick.WithLivenessCheck(lc),
+	)
+	if err != nil {
+		t.Fatalf("Failed to start container: %v", err)
+	}
+	defer func() {
+		cErr := ctr.Close(context.Background())
+		if cErr != nil {
+			t.Fatal(cErr)
+		}
+	}()
+
+	err = ctr.Stop(ctx, time.Second)
+	if err != nil {
+		t.Fatalf("Failed to stop container: %v", err)
+	}
+	if err = lc(ctr.Address()); err == nil {
+		t.Fatal("Expected stopped container to be unreachable")
+	}
+
+	err = ctr.Start(ctx)
+	if err != nil {
+		t.Fatalf("Failed to start container: %v", err)
+	}
+	bk := backoff.WithContext(backoff.NewExponentialBackOff(), ctx)
+	err = backoff.Retry(func() error { return lc(ctr.Address()) }, bk)
+	if err != nil {
+		t.Fatalf("Failed to reach restarted container: %v", err)
+	}
+}
+
+// syncBuffer is a bytes.Buffer safe for concurrent use.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestRestartLogs(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	ctr, err := podrick.StartContainer(ctx, "docker.io/kennethreitz/httpbin", "latest", "80",
+		podrick.WithLogger(podricktest.Logger(t)),
+		podrick.WithWaitForLog(regexp.MustCompile("Listening at"), 1),
+	)
+	if err != nil {
+		t.Fatalf("Failed to start container: %v", err)
+	}
+	defer func() {
+		cErr := ctr.Close(context.Background())
+		if cErr != nil {
+			t.Fatal(cErr)
+		}
+	}()
+
+	var logs syncBuffer
+	err = ctr.StreamLogs(ctx, &logs)
+	if err != nil {
+		t.Fatalf("Failed to stream logs: %v", err)
+	}
+
+	err = ctr.Restart(ctx, 500*time.Millisecond)
+	if err != nil {
+		t.Fatalf("Failed to restart container: %v", err)
+	}
+
+	listening := func() error {
+		if n := strings.Count(logs.String(), "Listening at"); n != 2 {
+			return fmt.Errorf("logged %d times", n)
+		}
+		return nil
+	}
+	bk := backoff.WithContext(backoff.NewConstantBackOff(100*time.Millisecond), ctx)
+	err = backoff.Retry(listening, bk)
+	if err != nil {
+		t.Fatalf("Failed to see logs after restart: %v", err)
+	}
+	// Logs written before the restart must not be repeated
+	time.Sleep(time.Second)
+	err = listening()
+	if err != nil {
+		t.Errorf("Unexpected logs after restart: %v", err)
+	}
+}
+
+func TestRunContainer(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	res, err := podrick.RunContainer(ctx, "docker.io/library/alpine", "3.10",
+		podrick.WithLogger(podricktest.Logger(t)),
+		podrick.WithCmd([]string{"sh", "-c", "echo hello; exit 2"}),
+	)
+	if err != nil {
+		t.Fatalf("Failed to run container: %v", err)
+	}
+
+	if res.ExitCode != 2 {
+		t.Errorf("Unexpected exit code: got %d, wanted %d", res.ExitCode, 2)
+	}
+	if !strings.Contains(string(res.Output), "hello") {
+		t.Errorf("Unexpected output: got %q, wanted it to contain %q", res.Output, "hello")
+	}
+}
+
+func TestWaitStrategy(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ctr, err := podrick.StartContainer(ctx, "docker.io/kennethreitz/httpbin", "latest", "80",
+		podrick.WithLogger(podricktest.Logger(t)),
+		podrick.WithWaitStrategy(podrick.All(
+			podrick.ForListeningPort("80"),
+			podrick.Any(
+				podrick.ForHTTP("80", "/status/418", http.StatusTeapot, regexp.MustCompile("teapot")),
+				podrick.ForLog(regexp.MustCompile("Listening at"), 1),
+			),
+			podrick.ForExec([]string{"true"}),
+		)),
+	)
+	if err != nil {
+		t.Fatalf("Failed to start container: %v", err)
+	}
+	defer func() {
+		cErr := ctr.Close(context.Background())
+		if cErr != nil {
+			t.Fatal(cErr)
+		}
+	}()
+
+	status, err := ctr.HealthStatus(ctx)
+	if err != nil {
+		t.Fatalf("Failed to get health status: %v", err)
+	}
+	if status != podrick.NoHealthcheck {
+		t.Errorf("Unexpected health status: got %q, wanted %q", status, podrick.NoHealthcheck)
+	}
+}
+
+func TestWaitForLog(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ctr, err := podrick.StartContainer(ctx, "docker.io/kennethreitz/httpbin", "latest", "80",
+		podrick.WithLogger(podricktest.Logger(t)),
+		podrick.WithWaitForLog(regexp.MustCompile("Booting worker"), 1),
+	)
+	if err != nil {
+		t.Fatalf("Failed to start container: %v", err)
+	}
+	defer func() {
+		cErr := ctr.Close(context.Background())
+		if cErr != nil {
+			t.Fatal(cErr)
+		}
+	}()
+
+	_, err = http.Get("http://" + ctr.Address() + "/get")
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStartupPolicy(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	lc := func(address string) error {
+		return errors.New("not ready")
+	}
+	_, err := podrick.StartContainer(ctx, "docker.io/kennethreitz/httpbin", "latest", "80",
+		podrick.WithLogger(podricktest.Logger(t)),
+		podrick.WithLivenessCheck(lc),
+		podrick.WithStartupPolicy(podrick.StartupPolicy{
+			Timeout:         2 * time.Second,
+			InitialInterval: 100 * time.Millisecond,
+			MaxInterval:     200 * time.Millisecond,
+		}),
+	)
+	if err == nil {
+		t.Fatal("Expected liveness check to fail")
+	}
+	if !strings.Contains(err.Error(), "attempts") || !strings.Contains(err.Error(), "not ready") {
+		t.Errorf("Expected error to describe attempts and last error: %v", err)
+	}
+}
+
+func TestMounts(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	dir, err := ioutil.TempDir("", "podrick")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	err = ioutil.WriteFile(filepath.Join(dir, "hello"), []byte("world"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctr, err := podrick.StartContainer(ctx, "docker.io/kennethreitz/httpbin", "latest", "80",
+		podrick.WithLogger(podricktest.Logger(t)),
+		podrick.WithMount(podrick.Mount{
+			Type:     podrick.BindMount,
+			Source:   dir,
+			Target:   "/data",
+			ReadOnly: true,
+		}),
+		podrick.WithMount(podrick.Mount{
+			Type:      podrick.TmpfsMount,
+			Target:    "/scratch",
+			TmpfsSize: 1 << 20,
+		}),
+	)
+	if err != nil {
+		t.Fatalf("Failed to start container: %v", err)
+	}
+	defer func() {
+		cErr := ctr.Close(context.Background())
+		if cErr != nil {
+			t.Fatal(cErr)
+		}
+	}()
+
+	res, err := ctr.Exec(ctx, []string{"cat", "/data/hello"}, podrick.ExecOptions{})
+	if err != nil {
+		t.Fatalf("Failed to exec in container: %v", err)
+	}
+	if string(res.Stdout) != "world" {
+		t.Errorf("Unexpected file content: got %q, wanted %q", res.Stdout, "world")
+	}
+
+	res, err = ctr.Exec(ctx, []string{"sh", "-c", "grep /scratch /proc/mounts"}, podrick.ExecOptions{})
+	if err != nil {
+		t.Fatalf("Failed to exec in container: %v", err)
+	}
+	if !strings.Contains(string(res.Stdout), "tmpfs") {
+		t.Errorf("Expected /scratch to be a tmpfs mount: %q", res.Stdout)
+	}
+}
+
+func TestCopyFrom(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ctr, err := podrick.StartContainer(ctx, "docker.io/kennethreitz/httpbin", "latest", "80",
+		podrick.WithLogger(podricktest.Logger(t)),
+	)
+	if err != nil {
+		t.Fatalf("Failed to start container: %v", err)
+	}
+	defer func() {
+		cErr := ctr.Close(context.Background())
+		if cErr != nil {
+			t.Fatal(cErr)
+		}
+	}()
+
+	_, err = ctr.Exec(ctx, []string{"sh", "-c", "mkdir /out && printf report > /out/coverage.txt"}, podrick.ExecOptions{})
+	if err != nil {
+		t.Fatalf("Failed to exec in container: %v", err)
+	}
+
+	content, err := podrick.CopyFileFrom(ctx, ctr, "/out/coverage.txt")
+	if err != nil {
+		t.Fatalf("Failed to copy file from container: %v", err)
+	}
+	if string(content) != "report" {
+		t.Errorf("Unexpected file content: got %q, wanted %q", content, "report")
+	}
+
+	rc, err := ctr.CopyFrom(ctx, "/out")
+	if err != nil {
+		t.Fatalf("Failed to copy directory from container: %v", err)
+	}
+	defer rc.Close()
+	var names []string
+	tr := tar.NewReader(rc)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, strings.TrimSuffix(hdr.Name, "/"))
+	}
+	want := []string{"out", "out/coverage.txt"}
+	if strings.Join(names, ",") != strings.Join(want, ",") {
+		t.Errorf("Unexpected archive entries: got %q, wanted %q", names, want)
+	}
+}
+
+func TestCopyTo(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ctr, err := podrick.StartContainer(ctx, "docker.io/kennethreitz/httpbin", "latest", "80",
+		podrick.WithLogger(podricktest.Logger(t)),
+	)
+	if err != nil {
+		t.Fatalf("Failed to start container: %v", err)
+	}
+	defer func() {
+		cErr := ctr.Close(context.Background())
+		if cErr != nil {
+			t.Fatal(cErr)
+		}
+	}()
+
+	for _, content := range []string{"first", "second"} {
+		err = ctr.CopyTo(ctx, podrick.File{
+			Content: strings.NewReader(content),
+			Path:    "/etc/app/config.txt",
+			Size:    len(content),
+			Mode:    0644,
+		})
+		if err != nil {
+			t.Fatalf("Failed to copy file to container: %v", err)
+		}
+		res, err := ctr.Exec(ctx, []string{"cat", "/etc/app/config.txt"}, podrick.ExecOptions{})
+		if err != nil {
+			t.Fatalf("Failed to exec in container: %v", err)
+		}
+		if string(res.Stdout) != content {
+			t.Errorf("Unexpected file content: got %q, wanted %q", res.Stdout, content)
+		}
+	}
+}
+
+func TestDirectoryUpload(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ctr, err := podrick.StartContainer(ctx, "docker.io/kennethreitz/httpbin", "latest", "80",
+		podrick.WithLogger(podricktest.Logger(t)),
+		podrick.WithDirectoryUpload(podrick.Directory{
+			Content: fstest.MapFS{
+				"conf/app.conf": {Data: []byte("key=value"), Mode: 0600},
+			},
+			Path: "/seed",
+			UID:  1000,
+			GID:  1000,
+		}),
+	)
+	if err != nil {
+		t.Fatalf("Failed to start container: %v", err)
+	}
+	defer func() {
+		cErr := ctr.Close(context.Background())
+		if cErr != nil {
+			t.Fatal(cErr)
+		}
+	}()
+
+	res, err := ctr.Exec(ctx, []string{"stat", "-c", "%u:%g %a", "/seed", "/seed/conf/app.conf"}, podrick.ExecOptions{})
+	if err != nil {
+		t.Fatalf("Failed to exec in container: %v", err)
+	}
+	want := "1000:1000 555\n1000:1000 600\n"
+	if string(res.Stdout) != want {
+		t.Errorf("Unexpected file ownership: got %q, wanted %q", res.Stdout, want)
+	}
+}
 
-func (t *testLogger) Trace(msg string, fields ...map[string]interface{}) {
-	msg = "] " + msg
-	if len(fields) > 0 {
-		for k, v := range fields[0] {
-			msg = fmt.Sprintf(k+": %v, ", v) + msg
+func TestNetwork(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	rt := &podman.Runtime{
+		Logger: podricktest.Logger(t),
+	}
+	err := rt.Connect(ctx)
+	if err != nil {
+		t.Fatalf("Failed to connect to podman: %v", err)
+	}
+	defer func() {
+		cErr := rt.Close(context.Background())
+		if cErr != nil {
+			t.Error(cErr)
 		}
+	}()
+
+	err = rt.CreateNetwork(ctx, "podrick-test")
+	if !errors.Is(err, podman.ErrNetworksNotSupported) {
+		t.Errorf("Unexpected error creating network: got %v, wanted %v", err, podman.ErrNetworksNotSupported)
+	}
+	_, err = rt.StartContainer(ctx, &podrick.ContainerConfig{
+		Repo:     "docker.io/kennethreitz/httpbin",
+		Tag:      "latest",
+		Port:     "80",
+		Networks: []podrick.NetworkAttachment{{Name: "podman"}, {Name: "podrick-test"}},
+	})
+	if !errors.Is(err, podman.ErrNetworksNotSupported) {
+		t.Errorf("Unexpected error attaching networks: got %v, wanted %v", err, podman.ErrNetworksNotSupported)
+	}
+
+	// podman is the default CNI network
+	ctr, err := rt.StartContainer(ctx, &podrick.ContainerConfig{
+		Repo:     "docker.io/kennethreitz/httpbin",
+		Tag:      "latest",
+		Port:     "80",
+		Networks: []podrick.NetworkAttachment{{Name: "podman"}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to start container: %v", err)
+	}
+	defer func() {
+		cErr := ctr.Close(context.Background())
+		if cErr != nil {
+			t.Error(cErr)
+		}
+	}()
+	ip, err := ctr.IP("podman")
+	if err != nil {
+		t.Fatalf("Failed to get container IP: %v", err)
+	}
+	primary, err := ctr.IP("")
+	if err != nil {
+		t.Fatalf("Failed to get container IP: %v", err)
+	}
+	if ip != primary {
+		t.Errorf("Unexpected IP on primary network: got %q, wanted %q", primary, ip)
 	}
-	msg = "TRACE [" + msg
-	t.Logf(msg)
 }
 
-func (t *testLogger) Debug(msg string, fields ...map[string]interface{}) {
-	msg = "] " + msg
-	if len(fields) > 0 {
-		for k, v := range fields[0] {
-			msg = fmt.Sprintf(k+": %v, ", v) + msg
+func TestInternalAddress(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ctr, err := podrick.StartContainer(ctx, "docker.io/kennethreitz/httpbin", "latest", "80",
+		podrick.WithLogger(podricktest.Logger(t)),
+		podrick.WithWaitStrategy(podrick.ForListeningPort("80")),
+	)
+	if err != nil {
+		t.Fatalf("Failed to start container: %v", err)
+	}
+	defer func() {
+		cErr := ctr.Close(context.Background())
+		if cErr != nil {
+			t.Fatal(cErr)
 		}
+	}()
+
+	if ctr.ID() == "" || ctr.Name() == "" {
+		t.Errorf("Unexpected container identity: ID %q, name %q", ctr.ID(), ctr.Name())
+	}
+	ip, err := ctr.IP("")
+	if err != nil {
+		t.Fatalf("Failed to get container IP: %v", err)
+	}
+	addr, err := ctr.InternalAddress("80")
+	if err != nil {
+		t.Fatalf("Failed to get internal address: %v", err)
+	}
+	if addr != net.JoinHostPort(ip, "80") {
+		t.Errorf("Unexpected internal address: got %q, wanted %q", addr, net.JoinHostPort(ip, "80"))
+	}
+	_, err = ctr.IP("podrick-no-such-network")
+	if err == nil {
+		t.Error("Expected error for unattached network")
+	}
+
+	res, err := podrick.RunContainer(ctx, "docker.io/library/alpine", "3.10",
+		podrick.WithLogger(podricktest.Logger(t)),
+		podrick.WithCmd([]string{"wget", "-q", "-O", "-", "http://" + addr + "/get"}),
+	)
+	if err != nil {
+		t.Fatalf("Failed to run container: %v", err)
+	}
+	if res.ExitCode != 0 {
+		t.Errorf("Failed to reach container by internal address: %s", res.Output)
 	}
-	msg = "DEBUG [" + msg
-	t.Logf(msg)
 }
 
-func (t *testLogger) Info(msg string, fields ...map[string]interface{}) {
-	msg = "] " + msg
-	if len(fields) > 0 {
-		for k, v := range fields[0] {
-			msg = fmt.Sprintf(k+": %v, ", v) + msg
+func TestPod(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	rt := &podman.Runtime{
+		Logger: podricktest.Logger(t),
+	}
+	err := rt.Connect(ctx)
+	if err != nil {
+		t.Fatalf("Failed to connect to podman: %v", err)
+	}
+	defer rt.Close(context.Background())
+
+	pod, err := rt.StartPod(ctx,
+		&podrick.ContainerConfig{
+			Repo: "docker.io/kennethreitz/httpbin",
+			Tag:  "latest",
+			Port: "80",
+		},
+		&podrick.ContainerConfig{
+			Repo: "docker.io/library/alpine",
+			Tag:  "3.10",
+			Cmd:  []string{"sleep", "3600"},
+		},
+	)
+	if err != nil {
+		t.Fatalf("Failed to start pod: %v", err)
+	}
+	defer func() {
+		cErr := pod.Close(context.Background())
+		if cErr != nil {
+			t.Fatal(cErr)
 		}
+	}()
+
+	ctrs := pod.Containers()
+	if len(ctrs) != 2 {
+		t.Fatalf("Unexpected number of containers: got %d, wanted %d", len(ctrs), 2)
 	}
-	msg = "INFO [" + msg
-	t.Logf(msg)
+	// Containers in a pod can reach each other on localhost
+	err = podrick.ForExec([]string{"wget", "-q", "-O", "-", "http://localhost:80/get"}).WaitUntilReady(ctx, ctrs[1])
+	if err != nil {
+		t.Fatalf("Failed to reach container in pod: %v", err)
+	}
+
+	address, err := pod.AddressForPort("80")
+	if err != nil {
+		t.Fatalf("Failed to get pod address: %v", err)
+	}
+	resp, err := http.Get("http://" + address + "/get")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
 }
 
-func (t *testLogger) Warn(msg string, fields ...map[string]interface{}) {
-	msg = "] " + msg
-	if len(fields) > 0 {
-		for k, v := range fields[0] {
-			msg = fmt.Sprintf(k+": %v, ", v) + msg
+func TestFixedHostPort(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ctr, err := podrick.StartContainer(ctx, "docker.io/kennethreitz/httpbin", "latest", "127.0.0.1:18080:80",
+		podrick.WithLogger(podricktest.Logger(t)),
+	)
+	if err != nil {
+		t.Fatalf("Failed to start container: %v", err)
+	}
+	defer func() {
+		cErr := ctr.Close(context.Background())
+		if cErr != nil {
+			t.Fatal(cErr)
 		}
+	}()
+
+	if ctr.Address() != "127.0.0.1:18080" {
+		t.Errorf("Unexpected address: got %q, wanted %q", ctr.Address(), "127.0.0.1:18080")
+	}
+
+	bindings := ctr.Ports()[podrick.Port{Number: "80", Protocol: "tcp"}]
+	if len(bindings) != 1 || bindings[0].HostIP != "127.0.0.1" || bindings[0].HostPort != "18080" {
+		t.Errorf("Unexpected port bindings: %+v", bindings)
+	}
+
+	_, err = podrick.StartContainer(ctx, "docker.io/kennethreitz/httpbin", "latest", "127.0.0.1:18080:80",
+		podrick.WithLogger(podricktest.Logger(t)),
+	)
+	if !errors.Is(err, podrick.ErrPortInUse) {
+		t.Errorf("Unexpected error: got %v, wanted %v", err, podrick.ErrPortInUse)
 	}
-	msg = "WARN [" + msg
-	t.Logf(msg)
 }
 
-func (t *testLogger) Error(msg string, fields ...map[string]interface{}) {
-	msg = "] " + msg
-	if len(fields) > 0 {
-		for k, v := range fields[0] {
-			msg = fmt.Sprintf(k+": %v, ", v) + msg
+func TestUDPPort(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ctr, err := podrick.StartContainer(ctx, "docker.io/library/alpine", "3.10", "5353/udp",
+		podrick.WithLogger(podricktest.Logger(t)),
+		podrick.WithExposePort("5353/tcp"),
+		podrick.WithCmd([]string{"sleep", "600"}),
+	)
+	if err != nil {
+		t.Fatalf("Failed to start container: %v", err)
+	}
+	defer func() {
+		cErr := ctr.Close(context.Background())
+		if cErr != nil {
+			t.Fatal(cErr)
 		}
+	}()
+
+	udpAddr, err := ctr.AddressForPortProto("5353", "udp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if udpAddr != ctr.Address() {
+		t.Errorf("Unexpected address: got %q, wanted %q", ctr.Address(), udpAddr)
+	}
+	tcpAddr, err := ctr.AddressForPort("5353")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tcpAddr == udpAddr {
+		t.Errorf("Expected different addresses for tcp and udp, got %q", tcpAddr)
+	}
+	_, err = ctr.AddressForPortProto("5353", "sctp")
+	if err == nil {
+		t.Error("Expected error for unexposed protocol")
+	}
+}
+
+func TestHostOverride(t *testing.T) {
+	err := os.Setenv(podrick.HostOverrideEnv, "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Unsetenv(podrick.HostOverrideEnv)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ctr, err := podrick.StartContainer(ctx, "docker.io/kennethreitz/httpbin", "latest", "80",
+		podrick.WithLogger(podricktest.Logger(t)),
+	)
+	if err != nil {
+		t.Fatalf("Failed to start container: %v", err)
+	}
+	defer func() {
+		cErr := ctr.Close(context.Background())
+		if cErr != nil {
+			t.Fatal(cErr)
+		}
+	}()
+
+	host, _, err := net.SplitHostPort(ctr.Address())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if host != "localhost" {
+		t.Errorf("Unexpected host: got %q, wanted %q", host, "localhost")
+	}
+}
+
+func TestStartContainerFromBuild(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	dir := t.TempDir()
+	dockerfile := "FROM docker.io/library/alpine:3.10\nARG GREETING\nRUN echo $GREETING > /greeting\nCMD [\"sleep\", \"600\"]\n"
+	err := ioutil.WriteFile(filepath.Join(dir, "Dockerfile"), []byte(dockerfile), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctr, err := podrick.StartContainerFromBuild(ctx, podrick.BuildConfig{
+		Repo:       "localhost/podrick-test-build",
+		Tag:        fmt.Sprint(time.Now().UnixNano()),
+		ContextDir: dir,
+		BuildArgs: map[string]string{
+			"GREETING": "hello",
+		},
+	}, "", podrick.WithLogger(podricktest.Logger(t)))
+	if err != nil {
+		t.Fatalf("Failed to start container: %v", err)
+	}
+	defer func() {
+		cErr := ctr.Close(context.Background())
+		if cErr != nil {
+			t.Fatal(cErr)
+		}
+	}()
+
+	res, err := ctr.Exec(ctx, []string{"cat", "/greeting"}, podrick.ExecOptions{})
+	if err != nil {
+		t.Fatalf("Failed to exec: %v", err)
+	}
+	if string(res.Stdout) != "hello\n" {
+		t.Errorf("Unexpected output: got %q, wanted %q", res.Stdout, "hello\n")
+	}
+}
+
+func TestPullPolicy(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	_, err := podrick.RunContainer(ctx, "docker.io/library/alpine", "podrick-not-present",
+		podrick.WithLogger(podricktest.Logger(t)),
+		podrick.WithPullPolicy(podrick.PullNever),
+	)
+	if !errors.Is(err, podrick.ErrImageNotPresent) {
+		t.Errorf("Unexpected error: got %v, wanted %v", err, podrick.ErrImageNotPresent)
+	}
+
+	res, err := podrick.RunContainer(ctx, "docker.io/library/alpine", "3.10",
+		podrick.WithLogger(podricktest.Logger(t)),
+		podrick.WithPullPolicy(podrick.PullAlways),
+		podrick.WithCmd([]string{"true"}),
+	)
+	if err != nil {
+		t.Fatalf("Failed to run container: %v", err)
+	}
+	if res.ExitCode != 0 {
+		t.Errorf("Unexpected exit code: got %d, wanted 0", res.ExitCode)
+	}
+}
+
+func TestReaper(t *testing.T) {
+	if os.Getenv("PODRICK_TEST_REAPER_HELPER") == "1" {
+		// Start a container and exit without closing it
+		volume := fmt.Sprintf("podrick-reaper-%d", time.Now().UnixNano())
+		ctr, err := podrick.StartContainer(context.Background(), "docker.io/library/alpine", "3.10", "",
+			podrick.WithLogger(podricktest.Logger(t)),
+			podrick.WithCmd([]string{"sleep", "600"}),
+			podrick.WithMount(podrick.Mount{
+				Type:   podrick.VolumeMount,
+				Source: volume,
+				Target: "/data",
+			}),
+		)
+		if err != nil {
+			t.Fatalf("Failed to start container: %v", err)
+		}
+		t.Logf("container:%s", ctr.ID())
+		t.Logf("volume:%s", volume)
+		return
+	}
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestReaper$", "-test.v")
+	cmd.Env = append(os.Environ(), "PODRICK_TEST_REAPER_HELPER=1", podrick.ReaperDisabledEnv+"=false")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("Failed to run helper: %v: %s", err, out)
+	}
+	m := regexp.MustCompile(`container:(\w+)`).FindSubmatch(out)
+	if m == nil {
+		t.Fatalf("Failed to find container in helper output: %s", out)
+	}
+	id := string(m[1])
+	m = regexp.MustCompile(`volume:([\w-]+)`).FindSubmatch(out)
+	if m == nil {
+		t.Fatalf("Failed to find volume in helper output: %s", out)
+	}
+	volume := string(m[1])
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	address := os.Getenv("PODMAN_VARLINK_ADDRESS")
+	if address == "" {
+		address = "unix:/run/podman/io.podman"
+	}
+	conn, err := varlink.NewConnection(ctx, address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	err = backoff.Retry(func() error {
+		_, err := iopodman.GetContainer().Call(ctx, conn, id)
+		var notFound *iopodman.ContainerNotFound
+		if errors.As(err, &notFound) {
+			return nil
+		}
+		if err != nil {
+			return backoff.Permanent(err)
+		}
+		return errors.New("container still exists")
+	}, backoff.WithContext(backoff.NewConstantBackOff(250*time.Millisecond), ctx))
+	if err != nil {
+		t.Fatalf("Container was not reaped: %v", err)
+	}
+	err = backoff.Retry(func() error {
+		vols, err := iopodman.GetVolumes().Call(ctx, conn, nil, true)
+		if err != nil {
+			return backoff.Permanent(err)
+		}
+		for _, v := range vols {
+			if v.Name == volume {
+				return errors.New("volume still exists")
+			}
+		}
+		return nil
+	}, backoff.WithContext(backoff.NewConstantBackOff(250*time.Millisecond), ctx))
+	if err != nil {
+		t.Fatalf("Volume was not reaped: %v", err)
+	}
+}
+
+func TestContainerLabels(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ctr, err := podrick.StartContainer(ctx, "docker.io/library/alpine", "3.10", "",
+		podrick.WithLogger(podricktest.Logger(t)),
+		podrick.WithCmd([]string{"sleep", "600"}),
+		podrick.WithLabels(map[string]string{"app": "podrick-test"}),
+		podrick.WithTestName(t.Name()),
+	)
+	if err != nil {
+		t.Fatalf("Failed to start container: %v", err)
+	}
+	defer func() {
+		cErr := ctr.Close(context.Background())
+		if cErr != nil {
+			t.Fatal(cErr)
+		}
+	}()
+
+	address := os.Getenv("PODMAN_VARLINK_ADDRESS")
+	if address == "" {
+		address = "unix:/run/podman/io.podman"
+	}
+	conn, err := varlink.NewConnection(ctx, address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	ct, err := iopodman.GetContainer().Call(ctx, conn, ctr.ID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	labels := ct.Labels
+
+	want := map[string]string{
+		"app":                "podrick-test",
+		podrick.TestLabel:    t.Name(),
+		podrick.SessionLabel: podrick.SessionID(),
+		podrick.VersionLabel: podrick.Version(),
+	}
+	for k, v := range want {
+		if labels[k] != v {
+			t.Errorf("Unexpected value for label %q: got %q, wanted %q", k, labels[k], v)
+		}
+	}
+}
+
+func TestPodricktest(t *testing.T) {
+	ctr := podricktest.Start(t, "docker.io/kennethreitz/httpbin", "latest", "80",
+		podrick.WithWaitStrategy(podrick.ForHTTP("80", "/status/200", http.StatusOK, nil)),
+	)
+
+	resp, err := http.Get("http://" + ctr.Address() + "/status/200")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Unexpected status code: got %d, wanted %d", resp.StatusCode, http.StatusOK)
 	}
-	msg = "ERROR [" + msg
-	t.Logf(msg)
 }
@@ -0,0 +1,90 @@
+package podman
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/uw-labs/podrick"
+	podman "github.com/uw-labs/podrick/runtimes/podman/iopodman"
+)
+
+// reaperName identifies the Podman runtime to reapers.
+const reaperName = "podman"
+
+// startReaper starts the reaper for the session,
+// logging a warning if it fails to start.
+func (r *Runtime) startReaper() {
+	err := podrick.StartReaper(reaperName)
+	if err != nil {
+		r.Logger.Warn("failed to start reaper", map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
+}
+
+// reap removes the pods, containers and
+// volumes labelled with the session ID.
+func reap(ctx context.Context, session string) (err error) {
+	r := &Runtime{}
+	err = r.Connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		cErr := r.Close(ctx)
+		if err == nil && cErr != nil {
+			err = fmt.Errorf("failed to close runtime: %w", cErr)
+		}
+	}()
+
+	pods, err := podman.ListPods().Call(ctx, r.conn)
+	if err != nil {
+		return fmt.Errorf("failed to list pods: %w", err)
+	}
+	var firstErr error
+	for _, pod := range pods {
+		if pod.Labels[podrick.SessionLabel] != session {
+			continue
+		}
+		_, err = podman.RemovePod().Call(ctx, r.conn, pod.Id, true)
+		if err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("failed to remove pod: %w", err)
+		}
+	}
+
+	ctrs, err := podman.ListContainers().Call(ctx, r.conn)
+	if err != nil {
+		return fmt.Errorf("failed to list containers: %w", err)
+	}
+	for _, ctr := range ctrs {
+		if ctr.Labels[podrick.SessionLabel] != session {
+			continue
+		}
+		_, err = podman.RemoveContainer().Call(ctx, r.conn, ctr.Id, true, true)
+		if err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("failed to remove container: %w", err)
+		}
+	}
+
+	vols, err := podman.GetVolumes().Call(ctx, r.conn, nil, true)
+	if err != nil {
+		return fmt.Errorf("failed to list volumes: %w", err)
+	}
+	var names []string
+	for _, v := range vols {
+		if v.Labels[podrick.SessionLabel] == session {
+			names = append(names, v.Name)
+		}
+	}
+	if len(names) > 0 {
+		_, err = podman.VolumeRemove().Call(ctx, r.conn, podman.VolumeRemoveOpts{
+			Volumes: names,
+			Force:   true,
+		})
+		if err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("failed to remove volumes: %w", err)
+		}
+	}
+
+	return firstErr
+}
@@ -0,0 +1,165 @@
+package podrick
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+var (
+	sharedMu sync.Mutex
+	shared   = map[string]*sharedContainer{}
+)
+
+type sharedContainer struct {
+	// ready is closed when start has returned.
+	ready chan struct{}
+	ctr   Container
+	err   error
+
+	// refs and closed are guarded by sharedMu.
+	refs   int
+	closed bool
+}
+
+// Shared returns the container shared with the key, calling start to
+// start it if no container is shared with the key. The key should
+// identify the configuration of the container, see SharedKey.
+// Shared is safe for concurrent use, such as from parallel tests,
+// and start is only called once while the container is in use.
+// The start funcs of different keys may run concurrently, which
+// the built-in runtimes support.
+//
+// Each successful call must be paired with a call to release. The
+// container is closed when it is released by its last user, or by
+// CloseShared. To share a container across sequential tests, hold a
+// reference in TestMain while running the tests, and call CloseShared
+// afterwards. Loggers passed to start must outlive the tests using
+// the container.
+func Shared(ctx context.Context, key string, start func(context.Context) (Container, error)) (_ Container, release func() error, err error) {
+	sharedMu.Lock()
+	sc, ok := shared[key]
+	if !ok {
+		sc = &sharedContainer{
+			ready: make(chan struct{}),
+		}
+		shared[key] = sc
+	}
+	sc.refs++
+	sharedMu.Unlock()
+
+	if !ok {
+		sc.ctr, sc.err = start(ctx)
+		close(sc.ready)
+	} else {
+		select {
+		case <-sc.ready:
+		case <-ctx.Done():
+			_ = sc.release(key)
+			return nil, nil, ctx.Err()
+		}
+	}
+	if sc.err != nil {
+		// Remove the container, so the next call starts it again
+		_ = sc.release(key)
+		return nil, nil, fmt.Errorf("failed to start shared container: %w", sc.err)
+	}
+
+	var once sync.Once
+	release = func() error {
+		err := fmt.Errorf("shared container %q already released", key)
+		once.Do(func() {
+			err = sc.release(key)
+		})
+		return err
+	}
+	return sc.ctr, release, nil
+}
+
+// SharedKey returns a key for Shared identifying the container
+// configured by the image, port and options. File and archive
+// contents, wait strategies, liveness checks and loggers are not
+// part of the key.
+func SharedKey(repo, tag, port string, opts ...Option) string {
+	conf := newConfig(repo, tag, port, opts...).ContainerConfig
+	conf.Files = append([]File(nil), conf.Files...)
+	for i := range conf.Files {
+		conf.Files[i].Content = nil
+	}
+	conf.Archives = append([]Archive(nil), conf.Archives...)
+	for i := range conf.Archives {
+		conf.Archives[i].Content = nil
+	}
+	// The config only contains plain values once
+	// the contents are removed, so this can't fail.
+	b, _ := json.Marshal(conf)
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// release drops a reference to the container, and
+// closes the container if it was the last one.
+func (sc *sharedContainer) release(key string) error {
+	sharedMu.Lock()
+	sc.refs--
+	last := sc.refs == 0 && !sc.closed
+	if last {
+		sc.closed = true
+		if shared[key] == sc {
+			delete(shared, key)
+		}
+	}
+	sharedMu.Unlock()
+
+	if !last {
+		return nil
+	}
+	return sc.close()
+}
+
+// close waits for the container to be started,
+// and closes it if it was started successfully.
+func (sc *sharedContainer) close() error {
+	<-sc.ready
+	if sc.ctr == nil {
+		return nil
+	}
+	err := sc.ctr.Close(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to close shared container: %w", err)
+	}
+	return nil
+}
+
+// CloseShared closes all shared containers, regardless of whether
+// they have been released. It should be called in TestMain, after
+// running the tests. Later calls to release have no effect.
+func CloseShared(ctx context.Context) error {
+	sharedMu.Lock()
+	var scs []*sharedContainer
+	for key, sc := range shared {
+		if !sc.closed {
+			sc.closed = true
+			scs = append(scs, sc)
+		}
+		delete(shared, key)
+	}
+	sharedMu.Unlock()
+
+	var firstErr error
+	for _, sc := range scs {
+		select {
+		case <-sc.ready:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		err := sc.close()
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,136 @@
+package podrick
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+type fakeContainer struct {
+	Container
+	closed int32
+}
+
+func (f *fakeContainer) Close(context.Context) error {
+	atomic.AddInt32(&f.closed, 1)
+	return nil
+}
+
+func TestShared(t *testing.T) {
+	var starts int32
+	ctr := &fakeContainer{}
+	start := func(context.Context) (Container, error) {
+		atomic.AddInt32(&starts, 1)
+		return ctr, nil
+	}
+
+	var wg sync.WaitGroup
+	releases := make([]func() error, 10)
+	for i := range releases {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			got, release, err := Shared(context.Background(), "TestShared", start)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if got != ctr {
+				t.Error("Unexpected container")
+			}
+			releases[i] = release
+		}(i)
+	}
+	wg.Wait()
+	if starts != 1 {
+		t.Fatalf("Unexpected number of starts: got %d, wanted 1", starts)
+	}
+
+	for i, release := range releases {
+		if ctr.closed != 0 {
+			t.Fatalf("Container closed after %d of %d releases", i, len(releases))
+		}
+		err := release()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if ctr.closed != 1 {
+		t.Errorf("Unexpected number of closes: got %d, wanted 1", ctr.closed)
+	}
+	if releases[0]() == nil {
+		t.Error("Expected error releasing twice")
+	}
+
+	// The container is started again once it has been closed
+	_, release, err := Shared(context.Background(), "TestShared", start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer release()
+	if starts != 2 {
+		t.Errorf("Unexpected number of starts: got %d, wanted 2", starts)
+	}
+}
+
+func TestSharedStartError(t *testing.T) {
+	startErr := errors.New("no runtime")
+	_, _, err := Shared(context.Background(), "TestSharedStartError", func(context.Context) (Container, error) {
+		return nil, startErr
+	})
+	if !errors.Is(err, startErr) {
+		t.Fatalf("Unexpected error: got %v, wanted %v", err, startErr)
+	}
+
+	ctr := &fakeContainer{}
+	got, release, err := Shared(context.Background(), "TestSharedStartError", func(context.Context) (Container, error) {
+		return ctr, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer release()
+	if got != ctr {
+		t.Error("Expected container to be started again after error")
+	}
+}
+
+func TestSharedKey(t *testing.T) {
+	key := SharedKey("postgres", "12", "5432", WithEnv([]string{"POSTGRES_PASSWORD=pass"}))
+	if got := SharedKey("postgres", "12", "5432", WithEnv([]string{"POSTGRES_PASSWORD=pass"})); got != key {
+		t.Errorf("Unexpected key for same config: got %q, wanted %q", got, key)
+	}
+	if got := SharedKey("postgres", "12", "5432"); got == key {
+		t.Error("Expected different key for different options")
+	}
+	if got := SharedKey("postgres", "13", "5432", WithEnv([]string{"POSTGRES_PASSWORD=pass"})); got == key {
+		t.Error("Expected different key for different image")
+	}
+}
+
+func TestCloseShared(t *testing.T) {
+	ctr := &fakeContainer{}
+	_, release, err := Shared(context.Background(), "TestCloseShared", func(context.Context) (Container, error) {
+		return ctr, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = CloseShared(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ctr.closed != 1 {
+		t.Errorf("Unexpected number of closes: got %d, wanted 1", ctr.closed)
+	}
+	err = release()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ctr.closed != 1 {
+		t.Errorf("Container closed again after release: got %d closes, wanted 1", ctr.closed)
+	}
+}
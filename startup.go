@@ -0,0 +1,55 @@
+package podrick
+
+import (
+	"time"
+
+	backoff "github.com/cenkalti/backoff/v3"
+)
+
+// defaultStartupTimeout is the time allowed for
+// a started container to become ready.
+const defaultStartupTimeout = 30 * time.Second
+
+// StartupPolicy configures how long a started container is given
+// to become ready, and how often its liveness check is retried.
+// All fields are optional.
+type StartupPolicy struct {
+	// Timeout is the total time allowed for the container to become
+	// ready, including any log waits, liveness check and wait strategies.
+	// Defaults to 30 seconds.
+	Timeout time.Duration
+	// InitialInterval is the interval before the first retry
+	// of the liveness check. The interval grows exponentially
+	// with each attempt. Defaults to 500 milliseconds.
+	InitialInterval time.Duration
+	// MaxInterval caps the interval between retries of the
+	// liveness check. Defaults to 60 seconds.
+	MaxInterval time.Duration
+	// BackOff, if set, is used to retry the liveness check
+	// instead of the exponential backoff described by
+	// the intervals. The Timeout still applies.
+	BackOff backoff.BackOff
+}
+
+func (p StartupPolicy) timeout() time.Duration {
+	if p.Timeout <= 0 {
+		return defaultStartupTimeout
+	}
+	return p.Timeout
+}
+
+func (p StartupPolicy) backOff() backoff.BackOff {
+	if p.BackOff != nil {
+		return p.BackOff
+	}
+	bk := backoff.NewExponentialBackOff()
+	// The timeout is enforced by the context.
+	bk.MaxElapsedTime = 0
+	if p.InitialInterval > 0 {
+		bk.InitialInterval = p.InitialInterval
+	}
+	if p.MaxInterval > 0 {
+		bk.MaxInterval = p.MaxInterval
+	}
+	return bk
+}
@@ -0,0 +1,306 @@
+package podrick
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	backoff "github.com/cenkalti/backoff/v3"
+)
+
+// WaitStrategy is used to ascertain the successful startup
+// of a container.
+type WaitStrategy interface {
+	// WaitUntilReady blocks until the container is ready,
+	// or returns an error if the context is cancelled
+	// before the container becomes ready.
+	WaitUntilReady(context.Context, Container) error
+}
+
+// WaitStrategyFunc allows a function to be used as a WaitStrategy.
+type WaitStrategyFunc func(context.Context, Container) error
+
+// WaitUntilReady calls f(ctx, ctr).
+func (f WaitStrategyFunc) WaitUntilReady(ctx context.Context, ctr Container) error {
+	return f(ctx, ctr)
+}
+
+// pollInterval is the interval between checks
+// of the polling wait strategies.
+const pollInterval = 250 * time.Millisecond
+
+// poll calls check until it does not error, or the context is cancelled,
+// in which case the last error is returned.
+func poll(ctx context.Context, check func(context.Context) error) error {
+	attempts := 0
+	err := backoff.Retry(
+		func() error {
+			attempts++
+			return check(ctx)
+		},
+		backoff.WithContext(backoff.NewConstantBackOff(pollInterval), ctx),
+	)
+	if err != nil {
+		return fmt.Errorf("failed after %d attempts: %w", attempts, err)
+	}
+	return nil
+}
+
+// ForListeningPort waits for the specified port of the container
+// to accept TCP connections.
+func ForListeningPort(port string) WaitStrategy {
+	return WaitStrategyFunc(func(ctx context.Context, ctr Container) error {
+		return poll(ctx, func(ctx context.Context) error {
+			address, err := ctr.AddressForPort(port)
+			if err != nil {
+				return backoff.Permanent(err)
+			}
+			var d net.Dialer
+			conn, err := d.DialContext(ctx, "tcp", address)
+			if err != nil {
+				return fmt.Errorf("failed to dial port %q: %w", port, err)
+			}
+			return conn.Close()
+		})
+	})
+}
+
+// ForHTTP waits for a GET request to the path on the specified port
+// of the container to return the status code. If body is not nil,
+// the response body must also match it.
+func ForHTTP(port, path string, statusCode int, body *regexp.Regexp) WaitStrategy {
+	return WaitStrategyFunc(func(ctx context.Context, ctr Container) error {
+		return poll(ctx, func(ctx context.Context) error {
+			address, err := ctr.AddressForPort(port)
+			if err != nil {
+				return backoff.Permanent(err)
+			}
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+address+path, nil)
+			if err != nil {
+				return backoff.Permanent(fmt.Errorf("failed to create request: %w", err))
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				return fmt.Errorf("failed to make request: %w", err)
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != statusCode {
+				return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+			}
+			if body != nil {
+				b, err := ioutil.ReadAll(resp.Body)
+				if err != nil {
+					return fmt.Errorf("failed to read response body: %w", err)
+				}
+				if !body.Match(b) {
+					return fmt.Errorf("response body did not match %q", body)
+				}
+			}
+			return nil
+		})
+	})
+}
+
+// ForLog waits for the container to log a line matching
+// the pattern the specified number of times. The logs
+// stop being streamed once the strategy returns.
+func ForLog(pattern *regexp.Regexp, occurrences int) WaitStrategy {
+	return WaitStrategyFunc(func(ctx context.Context, ctr Container) error {
+		m := newLogMatcher(pattern, occurrences)
+		// Writes fail once stopped, which stops the stream
+		defer m.stop()
+		err := ctr.StreamLogs(ctx, m)
+		if err != nil {
+			return fmt.Errorf("failed to stream container logs: %w", err)
+		}
+		return m.wait(ctx)
+	})
+}
+
+// ForExec waits for the command to exit successfully
+// when executed inside the container.
+func ForExec(cmd []string) WaitStrategy {
+	return WaitStrategyFunc(func(ctx context.Context, ctr Container) error {
+		return poll(ctx, func(ctx context.Context) error {
+			res, err := ctr.Exec(ctx, cmd, ExecOptions{})
+			if err != nil {
+				return err
+			}
+			if res.ExitCode != 0 {
+				return fmt.Errorf("command exited with code %d: %s", res.ExitCode, res.Stderr)
+			}
+			return nil
+		})
+	})
+}
+
+// ForHealthy waits for the healthcheck of the container
+// to report the container as healthy.
+func ForHealthy() WaitStrategy {
+	return WaitStrategyFunc(func(ctx context.Context, ctr Container) error {
+		return poll(ctx, func(ctx context.Context) error {
+			status, err := ctr.HealthStatus(ctx)
+			if err != nil {
+				return err
+			}
+			switch status {
+			case Healthy:
+				return nil
+			case NoHealthcheck:
+				return backoff.Permanent(errors.New("container has no healthcheck"))
+			default:
+				return fmt.Errorf("container is %s", status)
+			}
+		})
+	})
+}
+
+// All waits for all the strategies, in order.
+func All(strategies ...WaitStrategy) WaitStrategy {
+	return WaitStrategyFunc(func(ctx context.Context, ctr Container) error {
+		for _, s := range strategies {
+			err := s.WaitUntilReady(ctx, ctr)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Any waits for any of the strategies to succeed. The
+// strategies are waited for concurrently.
+func Any(strategies ...WaitStrategy) WaitStrategy {
+	return WaitStrategyFunc(func(ctx context.Context, ctr Container) error {
+		if len(strategies) == 0 {
+			return nil
+		}
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		errC := make(chan error, len(strategies))
+		for _, s := range strategies {
+			s := s
+			go func() {
+				errC <- s.WaitUntilReady(ctx, ctr)
+			}()
+		}
+		var errs []string
+		for range strategies {
+			err := <-errC
+			if err == nil {
+				return nil
+			}
+			errs = append(errs, err.Error())
+		}
+		return fmt.Errorf("no wait strategy succeeded: %s", strings.Join(errs, "; "))
+	})
+}
+
+// WithTimeout limits the time the strategy is waited for.
+func WithTimeout(timeout time.Duration, strategy WaitStrategy) WaitStrategy {
+	return WaitStrategyFunc(func(ctx context.Context, ctr Container) error {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		return strategy.WaitUntilReady(ctx, ctr)
+	})
+}
+
+// logTailSize is the number of log lines
+// kept to provide context for errors.
+const logTailSize = 10
+
+// logMatcher is a writer which counts the
+// lines written to it that match a pattern.
+type logMatcher struct {
+	pattern     *regexp.Regexp
+	occurrences int
+
+	mu      sync.Mutex
+	partial []byte
+	tail    []string
+	seen    int
+	done    chan struct{}
+	stopped bool
+}
+
+// errLogMatcherStopped is returned by writes
+// to a logMatcher after it has been stopped.
+var errLogMatcherStopped = errors.New("log matcher stopped")
+
+var _ io.Writer = (*logMatcher)(nil)
+
+func newLogMatcher(pattern *regexp.Regexp, occurrences int) *logMatcher {
+	m := &logMatcher{
+		pattern:     pattern,
+		occurrences: occurrences,
+		done:        make(chan struct{}),
+	}
+	if occurrences <= 0 {
+		close(m.done)
+	}
+	return m
+}
+
+func (m *logMatcher) Write(p []byte) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.stopped {
+		return 0, errLogMatcherStopped
+	}
+	m.partial = append(m.partial, p...)
+	for {
+		i := bytes.IndexByte(m.partial, '\n')
+		if i < 0 {
+			break
+		}
+		m.line(strings.TrimSuffix(string(m.partial[:i]), "\r"))
+		m.partial = m.partial[i+1:]
+	}
+	return len(p), nil
+}
+
+func (m *logMatcher) line(l string) {
+	m.tail = append(m.tail, l)
+	if len(m.tail) > logTailSize {
+		m.tail = m.tail[1:]
+	}
+	if m.seen >= m.occurrences || !m.pattern.MatchString(l) {
+		return
+	}
+	m.seen++
+	if m.seen == m.occurrences {
+		close(m.done)
+	}
+}
+
+// stop makes any further writes fail.
+func (m *logMatcher) stop() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.stopped = true
+}
+
+// wait blocks until the pattern has been matched the
+// required number of times, or the context is cancelled.
+func (m *logMatcher) wait(ctx context.Context) error {
+	select {
+	case <-m.done:
+		return nil
+	case <-ctx.Done():
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return fmt.Errorf(
+		"log pattern %q seen %d of %d times: %w; last log lines:\n%s",
+		m.pattern, m.seen, m.occurrences, ctx.Err(), strings.Join(m.tail, "\n"),
+	)
+}
@@ -0,0 +1,76 @@
+package podrick
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLogMatcher(t *testing.T) {
+	m := newLogMatcher(regexp.MustCompile("ready"), 2)
+	_, _ = m.Write([]byte("starting\nready to acc"))
+	_, _ = m.Write([]byte("ept connections\r\nrestarting\n"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := m.wait(ctx)
+	if err == nil {
+		t.Fatal("Expected error before second occurrence")
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Unexpected error: got %v, wanted %v", err, context.DeadlineExceeded)
+	}
+	if !strings.Contains(err.Error(), "ready to accept connections\nrestarting") {
+		t.Errorf("Expected error to contain last log lines: %v", err)
+	}
+
+	_, _ = m.Write([]byte("ready again\n"))
+	err = m.wait(context.Background())
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	m.stop()
+	_, err = m.Write([]byte("more logs\n"))
+	if !errors.Is(err, errLogMatcherStopped) {
+		t.Errorf("Unexpected error: got %v, wanted %v", err, errLogMatcherStopped)
+	}
+}
+
+func TestWaitCombinators(t *testing.T) {
+	succeed := WaitStrategyFunc(func(context.Context, Container) error {
+		return nil
+	})
+	fail := WaitStrategyFunc(func(context.Context, Container) error {
+		return errors.New("failed")
+	})
+	block := WaitStrategyFunc(func(ctx context.Context, _ Container) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	tests := []struct {
+		name    string
+		ws      WaitStrategy
+		wantErr bool
+	}{
+		{name: "All succeed", ws: All(succeed, succeed)},
+		{name: "All with failure", ws: All(succeed, fail), wantErr: true},
+		{name: "Any with failure", ws: Any(fail, succeed)},
+		{name: "Any blocked", ws: Any(block, succeed)},
+		{name: "Any fail", ws: Any(fail, fail), wantErr: true},
+		{name: "WithTimeout", ws: WithTimeout(10*time.Millisecond, block), wantErr: true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.ws.WaitUntilReady(context.Background(), nil)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Unexpected error: got %v, wanted error %t", err, tt.wantErr)
+			}
+		})
+	}
+}